@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Types of events
+const (
+	// EventConnect is a new connection of a client
+	EventConnect = "connect"
+	// EventAuthSuccess is a successful authentication of a client
+	EventAuthSuccess = "auth_success"
+	// EventAuthFailure is a failed authentication of a client
+	EventAuthFailure = "auth_failure"
+	// EventSessionStart is a new session with a process
+	EventSessionStart = "session_start"
+	// EventResize is a terminal resize requested by a client
+	EventResize = "resize"
+	// EventWriteDenied is input from a client not permitted to write
+	EventWriteDenied = "write_denied"
+	// EventPolicyDenied is a request of a client denied by the policy
+	EventPolicyDenied = "policy_denied"
+	// EventCommand is a command line submitted by a user
+	EventCommand = "command"
+	// EventDisconnect is a closed connection of a client
+	EventDisconnect = "disconnect"
+	// EventExit is the exit of the process of a session
+	EventExit = "exit"
+	// EventSessionKill is a session terminated by an administrator
+	EventSessionKill = "session_kill"
+	// EventShareCreate is a share link of a session minted by an administrator
+	EventShareCreate = "share_create"
+	// EventShareRevoke is a share link revoked by an administrator
+	EventShareRevoke = "share_revoke"
+)
+
+// Event is an audit event of a session.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	SessionID  string    `json:"session_id,omitempty"`
+	User       string    `json:"user,omitempty"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Argv       []string  `json:"argv,omitempty"`
+	PID        int       `json:"pid,omitempty"`
+	Columns    int       `json:"columns,omitempty"`
+	Rows       int       `json:"rows,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+}
+
+// Auditor records audit events.
+type Auditor interface {
+	Audit(event Event) error
+}
+
+// JSONAuditor writes events to a stream as JSON lines.
+type JSONAuditor struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+// NewJSONAuditor creates a new instance of JSONAuditor.
+func NewJSONAuditor(writer io.Writer) *JSONAuditor {
+	return &JSONAuditor{
+		writer: writer,
+	}
+}
+
+func (auditor *JSONAuditor) Audit(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal audit event")
+	}
+
+	auditor.mutex.Lock()
+	defer auditor.mutex.Unlock()
+
+	_, err = auditor.writer.Write(append(line, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "failed to write audit event")
+	}
+
+	return nil
+}
+
+// MultiAuditor records events with all the auditors.
+type MultiAuditor []Auditor
+
+func (auditors MultiAuditor) Audit(event Event) error {
+	var lastErr error
+	for _, auditor := range auditors {
+		if err := auditor.Audit(event); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
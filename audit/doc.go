@@ -0,0 +1,3 @@
+// Package audit provides structured audit events of sessions
+// and auditors writing them to logs.
+package audit
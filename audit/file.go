@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RotatingFile is an io.Writer appending to a file,
+// which is rotated when it grows beyond a size.
+// Rotated files are suffixed with `.1` for the newest to `.{max backups}`.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file  *os.File
+	size  int64
+	mutex sync.Mutex
+}
+
+// NewRotatingFile opens the file at path for appending.
+// The file is never rotated when maxSize is zero.
+func NewRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+func (rf *RotatingFile) Write(p []byte) (n int, err error) {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	var rotateErr error
+	if rf.maxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		// events are still written to the current file when it fails
+		rotateErr = rf.rotate()
+	}
+
+	n, err = rf.file.Write(p)
+	rf.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Close closes the file.
+func (rf *RotatingFile) Close() error {
+	rf.mutex.Lock()
+	defer rf.mutex.Unlock()
+
+	return rf.file.Close()
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open audit log `%s`", rf.path)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.Wrapf(err, "failed to stat audit log `%s`", rf.path)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	return nil
+}
+
+// rotate replaces the file with a new one.
+// The current file is kept open when it fails.
+func (rf *RotatingFile) rotate() error {
+	// the new file is created beside first, so that nothing is lost on failure
+	newPath := rf.path + ".new"
+	file, err := os.OpenFile(newPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rotate audit log `%s`", rf.path)
+	}
+
+	if rf.maxBackups > 0 {
+		for i := rf.maxBackups - 1; i > 0; i-- {
+			os.Rename(rf.backupPath(i), rf.backupPath(i+1))
+		}
+		if err := os.Rename(rf.path, rf.backupPath(1)); err != nil {
+			file.Close()
+			os.Remove(newPath)
+			return errors.Wrapf(err, "failed to rotate audit log `%s`", rf.path)
+		}
+	}
+	// the current file is replaced at once when no backup is kept
+	if err := os.Rename(newPath, rf.path); err != nil {
+		if rf.maxBackups > 0 {
+			os.Rename(rf.backupPath(1), rf.path)
+		}
+		file.Close()
+		os.Remove(newPath)
+		return errors.Wrapf(err, "failed to rotate audit log `%s`", rf.path)
+	}
+
+	rf.file.Close()
+	rf.file = file
+	rf.size = 0
+	return nil
+}
+
+func (rf *RotatingFile) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", rf.path, i)
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(%s) = %v", path, err)
+	}
+	return string(data)
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	rf, err := NewRotatingFile(path, 8, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() = %v", err)
+	}
+	defer rf.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) = %v", line, err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	} {
+		if got := readTestFile(t, path); got != want {
+			t.Errorf("%s contains %q, want %q", path, got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Stat(%s.3) = %v, want not exist", path, err)
+	}
+}
+
+func TestRotatingFileWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	rf, err := NewRotatingFile(path, 8, 0)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() = %v", err)
+	}
+	defer rf.Close()
+
+	rf.Write([]byte("first\n"))
+	if _, err := rf.Write([]byte("second\n")); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	if got := readTestFile(t, path); got != "second\n" {
+		t.Errorf("file contains %q, want %q", got, "second\n")
+	}
+}
+
+func TestRotatingFileKeepsWritingOnFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	rf, err := NewRotatingFile(path, 8, 1)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() = %v", err)
+	}
+	defer rf.Close()
+
+	rf.Write([]byte("first\n"))
+	// the new file can not be created in place of a directory
+	if err := os.Mkdir(path+".new", 0700); err != nil {
+		t.Fatalf("Mkdir() = %v", err)
+	}
+	n, err := rf.Write([]byte("second\n"))
+	if err == nil || n != len("second\n") {
+		t.Fatalf("Write() = %d, %v; want the line written with an error", n, err)
+	}
+	if got := readTestFile(t, path); got != "first\nsecond\n" {
+		t.Errorf("file contains %q, want both lines", got)
+	}
+
+	// rotated once the cause is removed
+	os.Remove(path + ".new")
+	if _, err := rf.Write([]byte("third\n")); err != nil {
+		t.Fatalf("Write() = %v", err)
+	}
+	if got := readTestFile(t, path); got != "third\n" {
+		t.Errorf("file contains %q, want %q", got, "third\n")
+	}
+	if got := readTestFile(t, path+".1"); got != "first\nsecond\n" {
+		t.Errorf("backup contains %q, want both lines", got)
+	}
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"os"
+)
+
+// Options configures the auditor built by New.
+type Options struct {
+	// File to append events to, `-` for the standard output
+	File string
+	// Size in megabytes at which the file is rotated, zero to disable rotation
+	MaxSize int
+	// Number of rotated files to keep
+	MaxBackups int
+	// Address of syslog to send events to, see NewSyslogAuditor
+	Syslog string
+}
+
+// New builds an auditor writing to all the destinations configured in options.
+// It returns nil when no destination is configured.
+func New(options *Options) (Auditor, error) {
+	auditors := MultiAuditor{}
+
+	switch options.File {
+	case "":
+	case "-":
+		auditors = append(auditors, NewJSONAuditor(os.Stdout))
+	default:
+		file, err := NewRotatingFile(options.File, int64(options.MaxSize)*1024*1024, options.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		auditors = append(auditors, NewJSONAuditor(file))
+	}
+
+	if options.Syslog != "" {
+		auditor, err := NewSyslogAuditor(options.Syslog)
+		if err != nil {
+			return nil, err
+		}
+		auditors = append(auditors, auditor)
+	}
+
+	switch len(auditors) {
+	case 0:
+		return nil, nil
+	case 1:
+		return auditors[0], nil
+	default:
+		return auditors, nil
+	}
+}
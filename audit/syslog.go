@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"log/syslog"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// NewSyslogAuditor creates a JSONAuditor sending events to syslog.
+// address is `local` for the local syslog daemon,
+// or formatted as `{network}://{host}:{port}` for a remote one.
+func NewSyslogAuditor(address string) (*JSONAuditor, error) {
+	priority := syslog.LOG_AUTH | syslog.LOG_INFO
+
+	var writer *syslog.Writer
+	var err error
+	if address == "local" {
+		writer, err = syslog.New(priority, "gotty")
+	} else {
+		parts := strings.SplitN(address, "://", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("malformed syslog address `%s`, expected `local` or `{network}://{host}:{port}`", address)
+		}
+		writer, err = syslog.Dial(parts[0], parts[1], priority, "gotty")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to syslog `%s`", address)
+	}
+
+	return NewJSONAuditor(writer), nil
+}
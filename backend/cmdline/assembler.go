@@ -0,0 +1,331 @@
+package cmdline
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineLength is the maximum number of characters kept for a line.
+	maxLineLength = 16 * 1024
+	// maxHistory is the maximum number of lines kept for history navigation.
+	maxHistory = 1000
+)
+
+var (
+	pasteStart = []byte("\x1b[200~")
+	pasteEnd   = []byte("\x1b[201~")
+)
+
+// Assembler reconstructs submitted lines from keystrokes,
+// emulating the line editing keys of readline in the emacs mode.
+// History navigation only covers lines submitted through the assembler,
+// and completion with tab is recorded as a tab character.
+// Lines are reconstructed only from the keystrokes pushed to the assembler,
+// so a line edited by several masters of a shared slave is reconstructed
+// as the part edited by each of them.
+// An Assembler is not safe for concurrent use.
+type Assembler struct {
+	line   []rune
+	cursor int
+
+	history []string
+	// position in history while navigating, len(history) for the line being edited
+	historyIndex int
+	draft        []rune
+
+	pasting bool
+	// bytes of incomplete sequences or characters
+	pending []byte
+}
+
+// NewAssembler creates a new instance of Assembler.
+func NewAssembler() *Assembler {
+	return &Assembler{}
+}
+
+// Push processes keystrokes and returns the lines submitted by them.
+// Empty lines are not returned.
+func (asm *Assembler) Push(p []byte) []string {
+	data := append(asm.pending, p...)
+	asm.pending = nil
+
+	var lines []string
+	for len(data) > 0 {
+		n, line, ok := asm.process(data)
+		if n == 0 {
+			// wait for the rest of the sequence
+			asm.pending = append([]byte{}, data...)
+			break
+		}
+		data = data[n:]
+		if ok {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// process consumes a keystroke at the beginning of data and
+// returns the number of bytes consumed and the line submitted if any.
+func (asm *Assembler) process(data []byte) (n int, line string, submitted bool) {
+	if asm.pasting {
+		return asm.paste(data), "", false
+	}
+
+	switch b := data[0]; b {
+	case '\r', '\n':
+		line, submitted = asm.submit()
+		return 1, line, submitted
+	case 0x1b:
+		return asm.escape(data), "", false
+	case 0x7f, 0x08: // backspace
+		asm.deleteBackward(1)
+	case 0x01: // ^A
+		asm.cursor = 0
+	case 0x02: // ^B
+		asm.moveCursor(-1)
+	case 0x03: // ^C
+		asm.reset()
+	case 0x04: // ^D
+		asm.deleteForward(1)
+	case 0x05: // ^E
+		asm.cursor = len(asm.line)
+	case 0x06: // ^F
+		asm.moveCursor(1)
+	case '\t':
+		asm.insert('\t')
+	case 0x0b: // ^K
+		asm.line = asm.line[:asm.cursor]
+	case 0x0e: // ^N
+		asm.historyNext()
+	case 0x10: // ^P
+		asm.historyPrevious()
+	case 0x15: // ^U
+		asm.line = append([]rune{}, asm.line[asm.cursor:]...)
+		asm.cursor = 0
+	case 0x17: // ^W
+		asm.deleteBackward(asm.cursor - asm.wordStart(asm.cursor))
+	default:
+		if b < 0x20 {
+			return 1, "", false
+		}
+		if !utf8.FullRune(data) {
+			return 0, "", false
+		}
+		c, size := utf8.DecodeRune(data)
+		asm.insert(c)
+		return size, "", false
+	}
+
+	return 1, "", false
+}
+
+// escape handles an escape sequence and returns its length,
+// or 0 when the sequence is incomplete.
+func (asm *Assembler) escape(data []byte) int {
+	if len(data) < 2 {
+		return 0
+	}
+
+	switch data[1] {
+	case '[':
+		end := 2
+		for end < len(data) && data[end] >= 0x20 && data[end] <= 0x3f {
+			end++
+		}
+		if end == len(data) {
+			return 0
+		}
+		asm.csi(string(data[2:end]), data[end])
+		return end + 1
+	case 'O':
+		if len(data) < 3 {
+			return 0
+		}
+		asm.csi("", data[2])
+		return 3
+	case 'b':
+		asm.cursor = asm.wordStart(asm.cursor)
+	case 'f':
+		asm.cursor = asm.wordEnd(asm.cursor)
+	case 'd':
+		asm.deleteForward(asm.wordEnd(asm.cursor) - asm.cursor)
+	case 0x7f, 0x08:
+		asm.deleteBackward(asm.cursor - asm.wordStart(asm.cursor))
+	}
+
+	return 2
+}
+
+func (asm *Assembler) csi(params string, final byte) {
+	switch final {
+	case 'A':
+		asm.historyPrevious()
+	case 'B':
+		asm.historyNext()
+	case 'C':
+		asm.moveCursor(1)
+	case 'D':
+		asm.moveCursor(-1)
+	case 'H':
+		asm.cursor = 0
+	case 'F':
+		asm.cursor = len(asm.line)
+	case '~':
+		switch params {
+		case "1", "7":
+			asm.cursor = 0
+		case "4", "8":
+			asm.cursor = len(asm.line)
+		case "3":
+			asm.deleteForward(1)
+		case "200":
+			asm.pasting = true
+		}
+	}
+}
+
+// paste inserts pasted text up to the end of bracketed paste
+// and returns the number of bytes consumed.
+func (asm *Assembler) paste(data []byte) int {
+	text := data
+	consumed := len(data)
+	if i := bytes.Index(data, pasteEnd); i >= 0 {
+		text = data[:i]
+		consumed = i + len(pasteEnd)
+		asm.pasting = false
+	} else {
+		// the end of paste may be split
+		for keep := len(pasteEnd) - 1; keep > 0; keep-- {
+			if len(data) >= keep && bytes.HasSuffix(data, pasteEnd[:keep]) {
+				text = data[:len(data)-keep]
+				consumed = len(text)
+				break
+			}
+		}
+		// incomplete characters are left for the next write
+		for i := len(text) - 1; i >= 0 && i >= len(text)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(text[i]) {
+				if !utf8.FullRune(text[i:]) {
+					text = text[:i]
+					consumed = i
+				}
+				break
+			}
+		}
+	}
+
+	for _, c := range strings.ReplaceAll(string(text), "\r", "\n") {
+		asm.insert(c)
+	}
+	return consumed
+}
+
+func (asm *Assembler) submit() (string, bool) {
+	line := string(asm.line)
+	asm.reset()
+	if strings.TrimSpace(line) == "" {
+		return "", false
+	}
+
+	asm.history = append(asm.history, line)
+	if len(asm.history) > maxHistory {
+		asm.history = asm.history[len(asm.history)-maxHistory:]
+	}
+	asm.historyIndex = len(asm.history)
+
+	return line, true
+}
+
+func (asm *Assembler) reset() {
+	asm.line = nil
+	asm.cursor = 0
+	asm.draft = nil
+	asm.historyIndex = len(asm.history)
+}
+
+func (asm *Assembler) insert(c rune) {
+	if len(asm.line) >= maxLineLength {
+		return
+	}
+	asm.line = append(asm.line, 0)
+	copy(asm.line[asm.cursor+1:], asm.line[asm.cursor:])
+	asm.line[asm.cursor] = c
+	asm.cursor++
+}
+
+func (asm *Assembler) moveCursor(delta int) {
+	asm.cursor += delta
+	if asm.cursor < 0 {
+		asm.cursor = 0
+	}
+	if asm.cursor > len(asm.line) {
+		asm.cursor = len(asm.line)
+	}
+}
+
+func (asm *Assembler) deleteBackward(n int) {
+	if n > asm.cursor {
+		n = asm.cursor
+	}
+	asm.line = append(asm.line[:asm.cursor-n], asm.line[asm.cursor:]...)
+	asm.cursor -= n
+}
+
+func (asm *Assembler) deleteForward(n int) {
+	if asm.cursor+n > len(asm.line) {
+		n = len(asm.line) - asm.cursor
+	}
+	asm.line = append(asm.line[:asm.cursor], asm.line[asm.cursor+n:]...)
+}
+
+// wordStart returns the position of the beginning of the word before pos.
+func (asm *Assembler) wordStart(pos int) int {
+	for pos > 0 && asm.line[pos-1] == ' ' {
+		pos--
+	}
+	for pos > 0 && asm.line[pos-1] != ' ' {
+		pos--
+	}
+	return pos
+}
+
+// wordEnd returns the position of the end of the word after pos.
+func (asm *Assembler) wordEnd(pos int) int {
+	for pos < len(asm.line) && asm.line[pos] == ' ' {
+		pos++
+	}
+	for pos < len(asm.line) && asm.line[pos] != ' ' {
+		pos++
+	}
+	return pos
+}
+
+func (asm *Assembler) historyPrevious() {
+	if asm.historyIndex == 0 {
+		return
+	}
+	if asm.historyIndex == len(asm.history) {
+		asm.draft = asm.line
+	}
+	asm.historyIndex--
+	asm.line = []rune(asm.history[asm.historyIndex])
+	asm.cursor = len(asm.line)
+}
+
+func (asm *Assembler) historyNext() {
+	if asm.historyIndex >= len(asm.history) {
+		return
+	}
+	asm.historyIndex++
+	if asm.historyIndex == len(asm.history) {
+		asm.line = asm.draft
+		asm.draft = nil
+	} else {
+		asm.line = []rune(asm.history[asm.historyIndex])
+	}
+	asm.cursor = len(asm.line)
+}
@@ -0,0 +1,92 @@
+package cmdline
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssembler(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   []string
+	}{
+		{name: "plain line", writes: []string{"ls -l\r"}, want: []string{"ls -l"}},
+		{name: "lines in one write", writes: []string{"a\rb\n"}, want: []string{"a", "b"}},
+		{name: "empty lines", writes: []string{"\r", "  \r"}},
+		{name: "unfinished line", writes: []string{"ls"}},
+		{name: "tab", writes: []string{"ls\tx\r"}, want: []string{"ls\tx"}},
+
+		{name: "backspace", writes: []string{"lss\x7f -l\r"}, want: []string{"ls -l"}},
+		{name: "ctrl-h", writes: []string{"lss\x08\r"}, want: []string{"ls"}},
+		{name: "backspace at beginning", writes: []string{"\x7f\x7fls\r"}, want: []string{"ls"}},
+		{name: "delete", writes: []string{"abc\x1b[D\x1b[3~\r"}, want: []string{"ab"}},
+		{name: "ctrl-d", writes: []string{"abc\x01\x04\r"}, want: []string{"bc"}},
+
+		{name: "arrow keys", writes: []string{"ech hello\x1b[H\x1b[C\x1b[C\x1b[Co\r"}, want: []string{"echo hello"}},
+		{name: "application arrow keys", writes: []string{"ab\x1bODX\r"}, want: []string{"aXb"}},
+		{name: "cursor beyond line", writes: []string{"ab\x1b[C\x1b[Cc\x1b[D\x1b[D\x1b[D\x1b[D_\r"}, want: []string{"_abc"}},
+		{name: "ctrl-a and ctrl-e", writes: []string{"world\x01hello \x05!\r"}, want: []string{"hello world!"}},
+		{name: "ctrl-b and ctrl-f", writes: []string{"ac\x02\x02\x06b\r"}, want: []string{"abc"}},
+		{name: "home and end keys", writes: []string{"b\x1b[1~a\x1b[4~c\r"}, want: []string{"abc"}},
+		{name: "word movement", writes: []string{"one three\x1bbtwo \x1bf!\r"}, want: []string{"one two three!"}},
+
+		{name: "ctrl-c", writes: []string{"rm -rf /\x03ls\r"}, want: []string{"ls"}},
+		{name: "ctrl-u", writes: []string{"rm -rf /\x15ls\r"}, want: []string{"ls"}},
+		{name: "ctrl-u in middle", writes: []string{"rm ls\x1b[D\x1b[D\x15\r"}, want: []string{"ls"}},
+		{name: "ctrl-k", writes: []string{"echo abc\x01\x1bf\x0b\r"}, want: []string{"echo"}},
+		{name: "ctrl-w", writes: []string{"git commit\x17status\r"}, want: []string{"git status"}},
+		{name: "alt-d", writes: []string{"one two\x1bb\x1bd\r"}, want: []string{"one "}},
+		{name: "alt-backspace", writes: []string{"one two\x1b\x7f\r"}, want: []string{"one "}},
+
+		{name: "history", writes: []string{"make\r", "\x1b[A\r"}, want: []string{"make", "make"}},
+		{name: "history edited", writes: []string{"make test\r", "ls\r", "\x1b[A\x1b[A\x7f\x7f\x7f\x7fbuild\r"}, want: []string{"make test", "ls", "make build"}},
+		{name: "history back to draft", writes: []string{"ls\r", "pwd\x1b[A\x1b[B\r"}, want: []string{"ls", "pwd"}},
+		{name: "history beyond oldest", writes: []string{"ls\r", "\x1b[A\x1b[A\x1b[A\r"}, want: []string{"ls", "ls"}},
+		{name: "ctrl-p and ctrl-n", writes: []string{"ls\r", "pwd\r", "\x10\x10\x0e\r"}, want: []string{"ls", "pwd", "pwd"}},
+		{name: "history reset by ctrl-c", writes: []string{"ls\r", "\x1b[A\x03\x1b[B\r"}, want: []string{"ls"}},
+
+		{name: "bracketed paste", writes: []string{"\x1b[200~echo \x7f\x01a\x1b[201~\r"}, want: []string{"echo \x7f\x01a"}},
+		{name: "bracketed paste with newlines", writes: []string{"\x1b[200~echo a\rb\x1b[201~\r"}, want: []string{"echo a\nb"}},
+		{name: "bracketed paste split", writes: []string{"\x1b[200~echo", " hi\x1b[20", "1~\r"}, want: []string{"echo hi"}},
+		{name: "bracketed paste with split character", writes: []string{"\x1b[200~caf\xc3", "\xa9\x1b[201~\r"}, want: []string{"café"}},
+
+		{name: "escape sequence split", writes: []string{"ab", "\x1b", "[", "D", "X\r"}, want: []string{"aXb"}},
+		{name: "escape sequence with parameters split", writes: []string{"abc\x1b[D\x1b[", "3", "~\r"}, want: []string{"ab"}},
+		{name: "character split", writes: []string{"caf\xc3", "\xa9\r"}, want: []string{"café"}},
+		{name: "unknown sequence", writes: []string{"a\x1b[5~b\x1b[1;5Cc\r"}, want: []string{"abc"}},
+		{name: "control characters", writes: []string{"a\x07\x1fb\r"}, want: []string{"ab"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asm := NewAssembler()
+			var got []string
+			for _, w := range tt.writes {
+				got = append(got, asm.Push([]byte(w))...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Push() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssemblerLimits(t *testing.T) {
+	asm := NewAssembler()
+	lines := asm.Push([]byte(strings.Repeat("a", maxLineLength+10) + "\r"))
+	if len(lines) != 1 {
+		t.Fatalf("%d lines submitted, want 1", len(lines))
+	}
+	if n := len([]rune(lines[0])); n != maxLineLength {
+		t.Errorf("line of %d characters submitted, want %d", n, maxLineLength)
+	}
+
+	for i := 0; i < maxHistory+10; i++ {
+		asm.Push([]byte("ls\r"))
+	}
+	if len(asm.history) != maxHistory {
+		t.Errorf("%d lines in history, want %d", len(asm.history), maxHistory)
+	}
+}
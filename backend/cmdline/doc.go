@@ -0,0 +1,3 @@
+// Package cmdline reconstructs command lines submitted to a slave
+// from the keystrokes sent by masters, for auditing.
+package cmdline
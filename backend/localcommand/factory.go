@@ -37,6 +37,11 @@ func (factory *Factory) Name() string {
 	return "local command"
 }
 
+// Command returns the command and the arguments given to every slave.
+func (factory *Factory) Command() (string, []string) {
+	return factory.command, factory.argv
+}
+
 func (factory *Factory) New(params map[string][]string) (server.Slave, error) {
 	argv := make([]string, len(factory.argv))
 	copy(argv, factory.argv)
@@ -1,19 +1,14 @@
 package localcommand
 
 import (
-	"log"
 	"os"
 	"os/exec"
-	"regexp"
-	"strings"
 	"syscall"
 	"time"
 	"unsafe"
 
 	"github.com/creack/pty"
-	"github.com/labbs/webtty/utils"
 	"github.com/pkg/errors"
-	"github.com/urfave/cli/v2"
 )
 
 const (
@@ -21,16 +16,12 @@ const (
 	DefaultCloseTimeout = 10 * time.Second
 )
 
-var BlackList cli.StringSlice
-
 type LocalCommand struct {
 	command string
 	argv    []string
 
 	closeSignal  syscall.Signal
 	closeTimeout time.Duration
-	logFile      *os.File
-	cmdBuffer    string
 
 	cmd       *exec.Cmd
 	pty       *os.File
@@ -45,10 +36,6 @@ func New(command string, argv []string, options ...Option) (*LocalCommand, error
 		return nil, errors.Wrapf(err, "failed to start command `%s`", command)
 	}
 	ptyClosed := make(chan struct{})
-	logFile, err := os.OpenFile("terminal.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to open log file")
-	}
 
 	lcmd := &LocalCommand{
 		command: command,
@@ -60,7 +47,6 @@ func New(command string, argv []string, options ...Option) (*LocalCommand, error
 		cmd:       cmd,
 		pty:       ptmx,
 		ptyClosed: ptyClosed,
-		logFile:   logFile,
 	}
 
 	for _, option := range options {
@@ -69,61 +55,24 @@ func New(command string, argv []string, options ...Option) (*LocalCommand, error
 
 	// When the process is closed by the user,
 	// close pty so that Read() on the pty breaks with an EOF.
-	// go func() {
-	// 	defer func() {
-	// 		lcmd.pty.Close()
-	// 		lcmd.cmd.Cancel()
-	// 		close(lcmd.ptyClosed)
-	// 	}()
+	go func() {
+		defer func() {
+			lcmd.pty.Close()
+			close(lcmd.ptyClosed)
+		}()
 
-	// 	lcmd.cmd.Wait()
-	// }()
+		lcmd.cmd.Wait()
+	}()
 
 	return lcmd, nil
 }
 
 func (lcmd *LocalCommand) Read(p []byte) (n int, err error) {
-	n, err = lcmd.pty.Read(p)
-	if err != nil {
-		return n, err
-	}
-
-	// Convertir le byte slice en string pour le traitement
-	output := string(p[:n])
-
-	// Tronquer ou masquer les informations sensibles
-	output = CatchAndTruncate(output)
-
-	// Convertir la string de sortie tronquée en byte slice et copier dans p
-	copy(p, []byte(output))
-
-	return n, err
+	return lcmd.pty.Read(p)
 }
 
 func (lcmd *LocalCommand) Write(p []byte) (n int, err error) {
-	n, err = lcmd.pty.Write(p)
-	if err != nil {
-		return n, err
-	}
-
-	output := GetTerminalState()
-
-	diff, _ := strings.CutPrefix(output, lcmd.cmdBuffer)
-
-	truncate := CatchAndTruncate(diff)
-
-	// _, errLog := lcmd.logFile.WriteString(truncate)
-	// if errLog != nil {
-	// 	return n, errLog
-	// }
-
-	if utils.RecordingEnabled {
-		go utils.PushRecording(truncate)
-	}
-
-	lcmd.cmdBuffer = output
-
-	return n, err
+	return lcmd.pty.Write(p)
 }
 
 func (lcmd *LocalCommand) Close() error {
@@ -140,6 +89,16 @@ func (lcmd *LocalCommand) Close() error {
 	}
 }
 
+// WaitExit blocks until the command exits and returns its exit code,
+// which is -1 when the command is terminated by a signal.
+func (lcmd *LocalCommand) WaitExit() int {
+	<-lcmd.ptyClosed
+	if lcmd.cmd.ProcessState == nil {
+		return -1
+	}
+	return lcmd.cmd.ProcessState.ExitCode()
+}
+
 func (lcmd *LocalCommand) WindowTitleVariables() map[string]interface{} {
 	return map[string]interface{}{
 		"command": lcmd.command,
@@ -180,48 +139,3 @@ func (lcmd *LocalCommand) closeTimeoutC() <-chan time.Time {
 
 	return make(chan time.Time)
 }
-
-func GetTerminalState() string {
-	// Exemple : exécutez une commande tmux pour obtenir l'historique du terminal.
-	// Vous devrez ajuster cela en fonction de votre mise en œuvre spécifique.
-	cmd := exec.Command("tmux", "capture-pane", "-p")
-	output, err := cmd.Output()
-	if err != nil {
-		log.Println("Error capturing tmux pane:", err)
-		return ""
-	}
-
-	cleanOutput := strings.TrimRight(string(output), "\n")
-	lines := strings.Split(cleanOutput, "\n")
-
-	// On ne veut pas afficher la dernière ligne, car elle est vide.
-	return strings.Join(lines[:len(lines)-1], "\n")
-}
-
-func CatchAndTruncate(s string) string {
-	lines := strings.Split(s, "\n")
-
-	for _, blackListed := range BlackList.Value() {
-		for i, line := range lines {
-			if strings.Contains(line, blackListed) {
-				lines[i] = string("[TRUNCATED]")
-			}
-			if isBase64(line) {
-				lines[i] = string("[TRUNCATED]")
-			}
-		}
-	}
-
-	s = strings.Join(lines, "\n")
-
-	return s
-}
-
-func isBase64(s string) bool {
-	// Regex for verifying base64 strings
-	base64RegExp := "^(?:[A-Za-z0-9+\\/]{4})*(?:[A-Za-z0-9+\\/]{2}==|[A-Za-z0-9+\\/]{3}=|[A-Za-z0-9+\\/]{4})$"
-	base64Re := regexp.MustCompile(base64RegExp)
-
-	// Verify if the string is base64
-	return base64Re.MatchString(s)
-}
@@ -0,0 +1,87 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/webtty"
+)
+
+// frame is an output event of a recording.
+type frame struct {
+	elapsed time.Duration
+	data    []byte
+}
+
+// recording is a parsed asciicast v2 file.
+type recording struct {
+	header webtty.AsciicastHeader
+	frames []frame
+}
+
+func parseAsciicast(r io.Reader) (*recording, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, errors.Wrapf(err, "failed to read asciicast header")
+		}
+		return nil, errors.New("empty asciicast file")
+	}
+	rec := &recording{}
+	err := json.Unmarshal(scanner.Bytes(), &rec.header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse asciicast header")
+	}
+	if rec.header.Version != 2 {
+		return nil, errors.Errorf("unsupported asciicast version `%d`", rec.header.Version)
+	}
+
+	for line := 2; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var event []interface{}
+		err := json.Unmarshal(scanner.Bytes(), &event)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse asciicast event at line %d", line)
+		}
+		if len(event) != 3 {
+			return nil, errors.Errorf("malformed asciicast event at line %d", line)
+		}
+		elapsed, ok1 := event[0].(float64)
+		typ, ok2 := event[1].(string)
+		data, ok3 := event[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			return nil, errors.Errorf("malformed asciicast event at line %d", line)
+		}
+
+		// only output is replayed, input is echoed back by the terminal anyway
+		if typ != webtty.EventOutput {
+			continue
+		}
+		rec.frames = append(rec.frames, frame{
+			elapsed: time.Duration(elapsed * float64(time.Second)),
+			data:    []byte(data),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read asciicast file")
+	}
+
+	return rec, nil
+}
+
+// duration returns the time of the last frame.
+func (rec *recording) duration() time.Duration {
+	if len(rec.frames) == 0 {
+		return 0
+	}
+	return rec.frames[len(rec.frames)-1].elapsed
+}
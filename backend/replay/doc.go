@@ -0,0 +1,3 @@
+// Package replay provides an implementation of webtty.Slave
+// that plays back a session recorded in the asciicast v2 format.
+package replay
@@ -0,0 +1,85 @@
+package replay
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/server"
+)
+
+type Options struct {
+	Speed float64
+}
+
+type Factory struct {
+	file      string
+	recording *recording
+	options   *Options
+}
+
+// NewFactory loads the recording in file, which is played back for each client.
+func NewFactory(file string, options *Options) (*Factory, error) {
+	if err := checkSpeed(options.Speed); err != nil {
+		return nil, errors.Wrapf(err, "invalid speed")
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open recording `%s`", file)
+	}
+	defer f.Close()
+
+	rec, err := parseAsciicast(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load recording `%s`", file)
+	}
+
+	return &Factory{
+		file:      file,
+		recording: rec,
+		options:   options,
+	}, nil
+}
+
+func (factory *Factory) Name() string {
+	return "replay"
+}
+
+// ControlParameters returns the parameters of New, which clients give even if
+// arguments are not permitted, as they only control the playback like the input.
+func (factory *Factory) ControlParameters() []string {
+	return []string{"speed", "seek"}
+}
+
+// Duration returns the length of the recording.
+func (factory *Factory) Duration() time.Duration {
+	return factory.recording.duration()
+}
+
+// New starts a playback.
+// `speed` and `seek` (in seconds) parameters override the initial speed and position.
+func (factory *Factory) New(params map[string][]string) (server.Slave, error) {
+	opts := []Option{WithSpeed(factory.options.Speed)}
+	if values := params["speed"]; len(values) > 0 {
+		speed, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid speed `%s`", values[0])
+		}
+		if err := checkSpeed(speed); err != nil {
+			return nil, errors.Wrapf(err, "invalid speed")
+		}
+		opts = append(opts, WithSpeed(speed))
+	}
+	if values := params["seek"]; len(values) > 0 {
+		seek, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid seek position `%s`", values[0])
+		}
+		opts = append(opts, WithSeek(time.Duration(seek*float64(time.Second))))
+	}
+
+	return newReplay(factory.file, factory.recording, opts...)
+}
@@ -0,0 +1,36 @@
+package replay
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Option func(*Replay)
+
+// WithSpeed sets the initial playback speed, 1.0 is the original speed.
+// Speeds out of the bounds of the +/- keys are clamped,
+// and speeds not positive or not finite are ignored.
+func WithSpeed(speed float64) Option {
+	return func(rp *Replay) {
+		if checkSpeed(speed) == nil {
+			rp.speed = math.Max(minSpeed, math.Min(maxSpeed, speed))
+		}
+	}
+}
+
+// WithSeek starts the playback from the position.
+func WithSeek(position time.Duration) Option {
+	return func(rp *Replay) {
+		rp.seek = position
+	}
+}
+
+// checkSpeed returns an error if speed can not be a playback speed.
+func checkSpeed(speed float64) error {
+	if math.IsNaN(speed) || math.IsInf(speed, 0) || speed <= 0 {
+		return errors.Errorf("speed must be a positive number, got `%g`", speed)
+	}
+	return nil
+}
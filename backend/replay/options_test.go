@@ -0,0 +1,50 @@
+package replay
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWithSpeed(t *testing.T) {
+	tests := []struct {
+		speed float64
+		want  float64
+	}{
+		{speed: 2, want: 2},
+		{speed: 0.5, want: 0.5},
+		{speed: 100, want: maxSpeed},
+		{speed: 0.001, want: minSpeed},
+		{speed: 0, want: DefaultSpeed},
+		{speed: -1, want: DefaultSpeed},
+		{speed: math.NaN(), want: DefaultSpeed},
+		{speed: math.Inf(1), want: DefaultSpeed},
+	}
+
+	for _, tt := range tests {
+		rp := &Replay{speed: DefaultSpeed}
+		WithSpeed(tt.speed)(rp)
+		if rp.speed != tt.want {
+			t.Errorf("WithSpeed(%g) sets speed %g, want %g", tt.speed, rp.speed, tt.want)
+		}
+	}
+}
+
+func TestFactorySpeedParameter(t *testing.T) {
+	factory := &Factory{recording: &recording{}, options: &Options{Speed: DefaultSpeed}}
+	for _, speed := range []string{"0", "-1", "NaN", "Inf", "fast"} {
+		if _, err := factory.New(map[string][]string{"speed": {speed}}); err == nil {
+			t.Errorf("New() with speed %s succeeded, want error", speed)
+		}
+	}
+}
+
+func TestNewFactoryRejectsSpeed(t *testing.T) {
+	for _, speed := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		// the speed is checked before the recording is loaded
+		_, err := NewFactory("missing.cast", &Options{Speed: speed})
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid speed") {
+			t.Errorf("NewFactory() with speed %g = %v, want invalid speed", speed, err)
+		}
+	}
+}
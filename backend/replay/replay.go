@@ -0,0 +1,253 @@
+package replay
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSpeed = 1.0
+	// seek step of the arrow keys
+	seekStep = 5 * time.Second
+	// speed bounds of the +/- keys
+	minSpeed = 1.0 / 16
+	maxSpeed = 16.0
+)
+
+type control int
+
+const (
+	controlTogglePause control = iota
+	controlFaster
+	controlSlower
+	controlForward
+	controlBackward
+	controlRestart
+)
+
+// Replay plays back output frames of a recording with the original timing.
+// Input is interpreted as playback controls:
+// space pauses and resumes, + and - change the speed,
+// right and left arrows seek by 5 seconds and 0 restarts.
+type Replay struct {
+	file      string
+	recording *recording
+
+	speed float64
+	seek  time.Duration
+
+	output    chan []byte
+	pending   []byte
+	controls  chan control
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newReplay(file string, rec *recording, options ...Option) (*Replay, error) {
+	rp := &Replay{
+		file:      file,
+		recording: rec,
+
+		speed: DefaultSpeed,
+
+		output:   make(chan []byte),
+		controls: make(chan control, 16),
+		done:     make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(rp)
+	}
+
+	go rp.play()
+
+	return rp, nil
+}
+
+func (rp *Replay) Read(p []byte) (n int, err error) {
+	if len(rp.pending) == 0 {
+		select {
+		case data := <-rp.output:
+			rp.pending = data
+		case <-rp.done:
+			return 0, io.EOF
+		}
+	}
+
+	n = copy(p, rp.pending)
+	rp.pending = rp.pending[n:]
+
+	return n, nil
+}
+
+func (rp *Replay) Write(p []byte) (n int, err error) {
+	input := p
+	for len(input) > 0 {
+		var c control
+		var ok bool
+		switch {
+		case bytes.HasPrefix(input, []byte("\x1b[C")):
+			c, ok = controlForward, true
+			input = input[3:]
+		case bytes.HasPrefix(input, []byte("\x1b[D")):
+			c, ok = controlBackward, true
+			input = input[3:]
+		default:
+			switch input[0] {
+			case ' ':
+				c, ok = controlTogglePause, true
+			case '+', '=':
+				c, ok = controlFaster, true
+			case '-':
+				c, ok = controlSlower, true
+			case '0':
+				c, ok = controlRestart, true
+			}
+			input = input[1:]
+		}
+
+		if ok {
+			select {
+			case rp.controls <- c:
+			case <-rp.done:
+				return 0, io.EOF
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+func (rp *Replay) Close() error {
+	rp.closeOnce.Do(func() {
+		close(rp.done)
+	})
+	return nil
+}
+
+func (rp *Replay) WindowTitleVariables() map[string]interface{} {
+	return map[string]interface{}{
+		"command": "replay",
+		"argv":    []string{rp.file},
+		"title":   rp.recording.header.Title,
+	}
+}
+
+// ResizeTerminal does nothing as the output has been recorded with a fixed size.
+func (rp *Replay) ResizeTerminal(width int, height int) error {
+	return nil
+}
+
+// play sends frames to the output as their time comes.
+func (rp *Replay) play() {
+	frames := rp.recording.frames
+	// position in the recording, which was at the anchor time
+	position := time.Duration(0)
+	anchor := time.Now()
+	paused := false
+	next := 0
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	current := func() time.Duration {
+		if paused {
+			return position
+		}
+		return position + time.Duration(float64(time.Since(anchor))*rp.speed)
+	}
+
+	// seekTo moves to the target position, sending the skipped frames at once
+	seekTo := func(target time.Duration) bool {
+		if target < 0 {
+			target = 0
+		}
+		var data []byte
+		if target < position {
+			data = []byte("\x1bc")
+			next = 0
+		}
+		for next < len(frames) && frames[next].elapsed <= target {
+			data = append(data, frames[next].data...)
+			next++
+		}
+		position = target
+		anchor = time.Now()
+		return len(data) == 0 || rp.send(data)
+	}
+
+	if !seekTo(rp.seek) {
+		return
+	}
+
+	for {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		var timerC <-chan time.Time
+		if !paused && next < len(frames) {
+			wait := time.Duration(float64(frames[next].elapsed-position) / rp.speed)
+			timer.Reset(wait)
+			timerC = timer.C
+		}
+
+		select {
+		case <-timerC:
+			position = frames[next].elapsed
+			anchor = time.Now()
+			if !rp.send(frames[next].data) {
+				return
+			}
+			next++
+
+		case c := <-rp.controls:
+			position = current()
+			anchor = time.Now()
+			if next < len(frames) && position > frames[next].elapsed {
+				position = frames[next].elapsed
+			}
+
+			switch c {
+			case controlTogglePause:
+				paused = !paused
+			case controlFaster:
+				if rp.speed < maxSpeed {
+					rp.speed *= 2
+				}
+			case controlSlower:
+				if rp.speed > minSpeed {
+					rp.speed /= 2
+				}
+			case controlForward:
+				if !seekTo(position + seekStep) {
+					return
+				}
+			case controlBackward:
+				if !seekTo(position - seekStep) {
+					return
+				}
+			case controlRestart:
+				if !seekTo(-1) {
+					return
+				}
+			}
+
+		case <-rp.done:
+			return
+		}
+	}
+}
+
+func (rp *Replay) send(data []byte) bool {
+	select {
+	case rp.output <- data:
+		return true
+	case <-rp.done:
+		return false
+	}
+}
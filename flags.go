@@ -1,8 +1,10 @@
 package main
 
 import (
-	"github.com/labbs/webtty/backend/localcommand"
-	"github.com/labbs/webtty/utils"
+	"strings"
+
+	"github.com/labbs/webtty/backend/replay"
+	"github.com/labbs/webtty/pkg/redact"
 	"github.com/urfave/cli/v2"
 	"github.com/urfave/cli/v2/altsrc"
 )
@@ -18,11 +20,18 @@ func flags() []cli.Flag {
 		},
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:        "address",
-			Usage:       "Address to listen on",
+			Usage:       "Address to listen on, unix:{path} for a Unix domain socket or systemd for sockets passed by systemd",
 			EnvVars:     []string{"ADDRESS"},
 			Value:       "0.0.0.0",
 			Destination: &appOptions.Address,
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "socket-mode",
+			Usage:       "Permissions of the Unix domain socket in octal",
+			EnvVars:     []string{"SOCKET_MODE"},
+			Value:       "0660",
+			Destination: &appOptions.SocketMode,
+		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:        "port",
 			Usage:       "Port to listen on",
@@ -57,6 +66,88 @@ func flags() []cli.Flag {
 			Value:       "",
 			Destination: &appOptions.Credential,
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "users-file",
+			Usage:       "Path to the users file authenticated with basic authentication instead of the credential, each line formatted as name:hash:role with a bcrypt or argon2 hash and the viewer or operator role",
+			EnvVars:     []string{"USERS_FILE"},
+			Destination: &appOptions.UsersFile,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "policy-file",
+			Usage:       "Path to the JSON policy file with rules of commands, arguments and URL parameters allowed for users, roles and groups",
+			EnvVars:     []string{"POLICY_FILE"},
+			Destination: &appOptions.PolicyFile,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "secret-key",
+			Usage:       "Secret key to sign cookies and tokens (a random key is used if not given, which invalidates them on restart)",
+			EnvVars:     []string{"SECRET_KEY"},
+			Destination: &appOptions.SecretKey,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "oidc-issuer",
+			Usage:       "Issuer URL of OpenID Connect to log in users with (OpenID Connect is disabled if not given)",
+			EnvVars:     []string{"OIDC_ISSUER"},
+			Destination: &appOptions.OIDCIssuer,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "oidc-client-id",
+			Usage:       "Client ID registered at the OpenID Connect issuer",
+			EnvVars:     []string{"OIDC_CLIENT_ID"},
+			Destination: &appOptions.OIDCClientID,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "oidc-client-secret",
+			Usage:       "Client secret registered at the OpenID Connect issuer",
+			EnvVars:     []string{"OIDC_CLIENT_SECRET"},
+			Destination: &appOptions.OIDCClientSecret,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "oidc-redirect-url",
+			Usage:       "Redirect URL registered at the OpenID Connect issuer (derived from requests if not given)",
+			EnvVars:     []string{"OIDC_REDIRECT_URL"},
+			Destination: &appOptions.OIDCRedirectURL,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "oidc-scope",
+			Usage:       "Scope requested in addition to openid",
+			EnvVars:     []string{"OIDC_SCOPE"},
+			Value:       cli.NewStringSlice("profile", "email"),
+			Destination: &oidcScopes,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "oidc-user-claim",
+			Usage:       "Claim of ID tokens to name users after (sub is used if missing)",
+			EnvVars:     []string{"OIDC_USER_CLAIM"},
+			Value:       "email",
+			Destination: &appOptions.OIDCUserClaim,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "oidc-groups-claim",
+			Usage:       "Claim of ID tokens listing groups of users",
+			EnvVars:     []string{"OIDC_GROUPS_CLAIM"},
+			Value:       "groups",
+			Destination: &appOptions.OIDCGroupsClaim,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "oidc-allowed-email",
+			Usage:       "Verified email of users allowed to log in (all users of the issuer are allowed if neither email nor group is given)",
+			EnvVars:     []string{"OIDC_ALLOWED_EMAIL"},
+			Destination: &oidcAllowedEmails,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "oidc-allowed-group",
+			Usage:       "Group of users allowed to log in",
+			EnvVars:     []string{"OIDC_ALLOWED_GROUP"},
+			Destination: &oidcAllowedGroups,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "oidc-session-lifetime",
+			Usage:       "Seconds users stay logged in with OpenID Connect",
+			EnvVars:     []string{"OIDC_SESSION_LIFETIME"},
+			Value:       43200,
+			Destination: &appOptions.OIDCSessionLifetime,
+		}),
 		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:        "enable-random-url",
 			Usage:       "Enable random URL generation",
@@ -71,6 +162,13 @@ func flags() []cli.Flag {
 			Value:       8,
 			Destination: &appOptions.RandomUrlLength,
 		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "rotate-random-url",
+			Usage:       "Generate a new random URL after each session instead of exiting in the once mode",
+			EnvVars:     []string{"ROTATE_RANDOM_URL"},
+			Value:       false,
+			Destination: &appOptions.RotateRandomUrl,
+		}),
 		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:        "enable-tls",
 			Usage:       "Enable TLS",
@@ -92,6 +190,20 @@ func flags() []cli.Flag {
 			Value:       "~/.gotty.key",
 			Destination: &appOptions.TLSKeyFile,
 		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "tls-self-signed",
+			Usage:       "Generate a self-signed certificate and key at the TLS crt and key file paths if they do not exist",
+			EnvVars:     []string{"TLS_SELF_SIGNED"},
+			Value:       false,
+			Destination: &appOptions.TLSSelfSigned,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "tls-reload",
+			Usage:       "Reload the TLS certificate when its files are modified or on SIGHUP",
+			EnvVars:     []string{"TLS_RELOAD"},
+			Value:       false,
+			Destination: &appOptions.TLSReload,
+		}),
 		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:        "enable-tls-client-auth",
 			Usage:       "Enable TLS client authentication",
@@ -106,6 +218,18 @@ func flags() []cli.Flag {
 			Value:       "~/.gotty.ca.crt",
 			Destination: &appOptions.TLSCACrtFile,
 		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "tls-client-allowed-cn",
+			Usage:       "Common name of TLS client certificates allowed to connect (all verified certificates are allowed if neither CN nor OU is given)",
+			EnvVars:     []string{"TLS_CLIENT_ALLOWED_CN"},
+			Destination: &tlsClientAllowedCNs,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "tls-client-allowed-ou",
+			Usage:       "Organizational unit of TLS client certificates allowed to connect",
+			EnvVars:     []string{"TLS_CLIENT_ALLOWED_OU"},
+			Destination: &tlsClientAllowedOUs,
+		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:        "index-file",
 			Usage:       "Path to the index file",
@@ -190,6 +314,34 @@ func flags() []cli.Flag {
 			Value:       "xterm",
 			Destination: &appOptions.Term,
 		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "enable-session-sharing",
+			Usage:       "Enable clients to attach to an existing session with its session ID",
+			EnvVars:     []string{"ENABLE_SESSION_SHARING"},
+			Value:       false,
+			Destination: &appOptions.EnableSessionSharing,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "shared-read-only",
+			Usage:       "Attach clients joining an existing session as read-only",
+			EnvVars:     []string{"SHARED_READ_ONLY"},
+			Value:       false,
+			Destination: &appOptions.SharedReadOnly,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "session-grace-period",
+			Usage:       "Time in seconds to keep a session alive for its clients to resume after disconnection",
+			EnvVars:     []string{"SESSION_GRACE_PERIOD"},
+			Value:       0,
+			Destination: &appOptions.SessionGracePeriod,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "scrollback-size",
+			Usage:       "Size in kilobytes of the latest output replayed to clients attaching to a session",
+			EnvVars:     []string{"SCROLLBACK_SIZE"},
+			Value:       64,
+			Destination: &appOptions.ScrollbackSize,
+		}),
 		altsrc.NewIntFlag(&cli.IntFlag{
 			Name:        "close-signal",
 			Usage:       "Signal sent to the command process when gotty close it",
@@ -206,23 +358,184 @@ func flags() []cli.Flag {
 		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:        "recording-url",
-			Usage:       "URL to send recording data",
+			Usage:       "URL to send recording data with POST requests",
 			EnvVars:     []string{"RECORDING_URL"},
 			Value:       "",
-			Destination: &utils.RecordingUrl,
+			Destination: &recordingOptions.URL,
 		}),
 		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:        "recording-enabled",
 			Usage:       "Enable recording",
 			EnvVars:     []string{"RECORDING_ENABLED"},
 			Value:       true,
-			Destination: &utils.RecordingEnabled,
+			Destination: &recordingOptions.Enabled,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "recording-dir",
+			Usage:       "Directory to save session recordings in the asciicast v2 format (disabled when empty)",
+			EnvVars:     []string{"RECORDING_DIR"},
+			Value:       "",
+			Destination: &recordingOptions.Dir,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "recording-stdout",
+			Usage:       "Write recording data to the standard output as JSON lines",
+			EnvVars:     []string{"RECORDING_STDOUT"},
+			Value:       false,
+			Destination: &recordingOptions.Stdout,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "recording-batch-size",
+			Usage:       "Number of events sent in a request to the recording URL",
+			EnvVars:     []string{"RECORDING_BATCH_SIZE"},
+			Value:       100,
+			Destination: &recordingOptions.BatchSize,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "recording-flush-interval",
+			Usage:       "Time in seconds between requests to the recording URL",
+			EnvVars:     []string{"RECORDING_FLUSH_INTERVAL"},
+			Value:       5,
+			Destination: &recordingOptions.FlushInterval,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "recording-max-retries",
+			Usage:       "Number of retries of a failed request to the recording URL",
+			EnvVars:     []string{"RECORDING_MAX_RETRIES"},
+			Value:       3,
+			Destination: &recordingOptions.MaxRetries,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "admin-credential",
+			Usage:       "Credential for Basic Authentication of the admin API (ex: admin:pass), the API is disabled if empty",
+			EnvVars:     []string{"ADMIN_CREDENTIAL"},
+			Destination: &appOptions.AdminCredential,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "enable-metrics",
+			Usage:       "Expose Prometheus metrics at /metrics",
+			EnvVars:     []string{"ENABLE_METRICS"},
+			Value:       false,
+			Destination: &appOptions.EnableMetrics,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "metrics-address",
+			Usage:       "Address to serve metrics on separately from the terminal, e.g. 127.0.0.1:9090",
+			EnvVars:     []string{"METRICS_ADDRESS"},
+			Destination: &appOptions.MetricsAddress,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "audit-log",
+			Usage:       "File to append audit events of sessions to as JSON lines, - for the standard output",
+			EnvVars:     []string{"AUDIT_LOG"},
+			Destination: &auditOptions.File,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "audit-log-max-size",
+			Value:       100,
+			Usage:       "Size in megabytes at which the audit log is rotated (0 to disable rotation)",
+			EnvVars:     []string{"AUDIT_LOG_MAX_SIZE"},
+			Destination: &auditOptions.MaxSize,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "audit-log-max-backups",
+			Value:       5,
+			Usage:       "Number of rotated audit logs to keep",
+			EnvVars:     []string{"AUDIT_LOG_MAX_BACKUPS"},
+			Destination: &auditOptions.MaxBackups,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "audit-syslog",
+			Usage:       "Syslog to send audit events to, local or {network}://{host}:{port}",
+			EnvVars:     []string{"AUDIT_SYSLOG"},
+			Destination: &auditOptions.Syslog,
 		}),
 		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
 			Name:        "blacklist",
-			Usage:       "Blacklist of word to be censored",
+			Usage:       "Blacklist of word to be censored in output sent to clients",
 			EnvVars:     []string{"BLACKLIST"},
-			Destination: &localcommand.BlackList,
+			Destination: &blackList,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "redact-detector",
+			Usage:       "Builtin detector of secrets to be censored in output sent to clients (" + strings.Join(redact.BuiltinDetectorNames(), ", ") + ", all or none)",
+			EnvVars:     []string{"REDACT_DETECTOR"},
+			Destination: &redactDetectors,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "redact-rule",
+			Usage:       "Rule of secrets to be censored in output sent to clients formatted as `name=regexp`, only the group named `secret` is censored if any",
+			EnvVars:     []string{"REDACT_RULE"},
+			Destination: &redactRules,
 		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "recording-blacklist",
+			Usage:       "Blacklist of word to be censored in recordings (recordings are censored like output sent to clients if no recording-* censoring option is set)",
+			EnvVars:     []string{"RECORDING_BLACKLIST"},
+			Destination: &recordingBlackList,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "recording-redact-detector",
+			Usage:       "Builtin detector of secrets to be censored in recordings (" + strings.Join(redact.BuiltinDetectorNames(), ", ") + ", all or none)",
+			EnvVars:     []string{"RECORDING_REDACT_DETECTOR"},
+			Destination: &recordingRedactDetectors,
+		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:        "recording-redact-rule",
+			Usage:       "Rule of secrets to be censored in recordings formatted as `name=regexp`",
+			EnvVars:     []string{"RECORDING_REDACT_RULE"},
+			Destination: &recordingRedactRules,
+		}),
+	}
+}
+
+func replayFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.Float64Flag{
+			Name:        "speed",
+			Usage:       "Initial playback speed",
+			Value:       replay.DefaultSpeed,
+			Destination: &replayOptions.Speed,
+		},
+	}
+}
+
+func shareFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "server",
+			Usage:       "URL of the server to manage share links of (ex: https://example.com:8080/)",
+			Value:       "http://127.0.0.1:8080/",
+			EnvVars:     []string{"SHARE_SERVER"},
+			Destination: &shareClientOptions.Server,
+		},
+		&cli.BoolFlag{
+			Name:        "insecure",
+			Usage:       "Skip verifying the TLS certificate of the server",
+			Destination: &shareClientOptions.InsecureSkipVerify,
+		},
+	}
+}
+
+func shareCreateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "mode",
+			Usage:       "Access granted by the link, read-only or read-write",
+			Value:       "read-only",
+			Destination: &shareClientOptions.Mode,
+		},
+		&cli.IntFlag{
+			Name:        "ttl",
+			Usage:       "Lifetime of the link in seconds",
+			Value:       3600,
+			Destination: &shareClientOptions.TTL,
+		},
+		&cli.IntFlag{
+			Name:        "max-uses",
+			Usage:       "Maximum number of connections with the link (0 to disable)",
+			Value:       0,
+			Destination: &shareClientOptions.MaxUses,
+		},
 	}
 }
@@ -5,6 +5,8 @@ var helpTemplate string = `NAME:
 
 USAGE:
    {{.Name}} [options] <command> [<arguments...>]
+   {{.Name}} [options] replay [--speed <speed>] <recording file>
+   {{.Name}} [--admin-credential <user:pass>] share [--server <url>] create|list|revoke [<arguments...>]
 
 VERSION:
    {{.Version}}{{if or .Author .Email}}
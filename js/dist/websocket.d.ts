@@ -7,6 +7,7 @@ export declare class ConnectionFactory {
 export declare class Connection {
     bare: WebSocket;
     constructor(url: string, protocols: string[]);
+    protocol(): string;
     open(): void;
     close(): void;
     send(data: string): void;
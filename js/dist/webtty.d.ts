@@ -1,3 +1,5 @@
+export declare const protocolText = "webtty";
+export declare const protocolBinary = "webtty.binary";
 export declare const protocols: string[];
 export declare const msgInputUnknown = "0";
 export declare const msgInput = "1";
@@ -9,6 +11,8 @@ export declare const msgPong = "2";
 export declare const msgSetWindowTitle = "3";
 export declare const msgSetPreferences = "4";
 export declare const msgSetReconnect = "5";
+export declare const msgSetSession = "6";
+export declare const msgSessionEvent = "7";
 export interface Terminal {
     info(): {
         columns: number;
@@ -26,6 +30,7 @@ export interface Terminal {
     close(): void;
 }
 export interface Connection {
+    protocol(): string;
     open(): void;
     close(): void;
     send(data: string): void;
@@ -43,6 +48,10 @@ export declare class WebTTY {
     args: string;
     authToken: string;
     reconnect: number;
+    sessionID: string;
+    sessionToken: string;
     constructor(term: Terminal, connectionFactory: ConnectionFactory, args: string, authToken: string);
     open(): () => void;
 }
+export declare function encodeUTF8(data: string): string;
+export declare function decodeUTF8(data: string): string;
@@ -22,10 +22,13 @@ if (elem !== null) {
     if (pathName.slice(-1) != "/") {
         pathName += "/";
     }
-    const url = (httpsEnabled ? 'wss://' : 'ws://') + window.location.host + pathName + 'ws';
+    // share links authorize the requests of the page instead of credentials
+    const share = new URLSearchParams(window.location.search).get('share');
+    const shareQuery = share ? '?share=' + encodeURIComponent(share) : '';
+    const url = (httpsEnabled ? 'wss://' : 'ws://') + window.location.host + pathName + 'ws' + shareQuery;
     const args = window.location.search;
     const factory = new ConnectionFactory(url, protocols);
-    const wt = new WebTTY(term, factory, args, gotty_auth_token);
+    const wt = new WebTTY(term, factory, args, gotty_auth_token, pathName + 'token' + shareQuery);
     const closer = wt.open();
 
     window.addEventListener("unload", () => {
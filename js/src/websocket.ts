@@ -1,3 +1,5 @@
+import { protocolBinary, encodeUTF8 } from "./webtty";
+
 export class ConnectionFactory {
     url: string;
     protocols: string[];
@@ -18,8 +20,13 @@ export class Connection {
 
     constructor(url: string, protocols: string[]) {
         this.bare = new WebSocket(url, protocols);
+        this.bare.binaryType = "arraybuffer";
     }
 
+    protocol(): string {
+        return this.bare.protocol;
+    };
+
     open() {
         // nothing todo for websocket
     };
@@ -29,7 +36,17 @@ export class Connection {
     };
 
     send(data: string) {
-        this.bare.send(data);
+        if (this.protocol() != protocolBinary) {
+            this.bare.send(data);
+            return;
+        }
+
+        const bytes = encodeUTF8(data);
+        const buffer = new Uint8Array(bytes.length);
+        for (let i = 0; i < bytes.length; i++) {
+            buffer[i] = bytes.charCodeAt(i);
+        }
+        this.bare.send(buffer.buffer);
     };
 
     isOpen(): boolean {
@@ -48,7 +65,18 @@ export class Connection {
 
     onReceive(callback: (data: string) => void) {
         this.bare.onmessage = (event) => {
-            callback(event.data);
+            if (typeof event.data == "string") {
+                callback(event.data);
+                return;
+            }
+
+            // binary frames are passed as a string of bytes
+            const buffer = new Uint8Array(event.data);
+            let data = "";
+            for (let i = 0; i < buffer.length; i += 8192) {
+                data += String.fromCharCode.apply(null, buffer.subarray(i, i + 8192));
+            }
+            callback(data);
         }
     };
 
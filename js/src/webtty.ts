@@ -1,4 +1,6 @@
-export const protocols = ["webtty"];
+export const protocolText = "webtty";
+export const protocolBinary = "webtty.binary";
+export const protocols = [protocolBinary, protocolText];
 
 export const msgInputUnknown = '0';
 export const msgInput = '1';
@@ -11,6 +13,8 @@ export const msgPong = '2';
 export const msgSetWindowTitle = '3';
 export const msgSetPreferences = '4';
 export const msgSetReconnect = '5';
+export const msgSetSession = '6';
+export const msgSessionEvent = '7';
 
 
 export interface Terminal {
@@ -28,6 +32,7 @@ export interface Terminal {
 }
 
 export interface Connection {
+    protocol(): string;
     open(): void;
     close(): void;
     send(data: string): void;
@@ -47,29 +52,39 @@ export class WebTTY {
     connectionFactory: ConnectionFactory;
     args: string;
     authToken: string;
+    // URL to get a new auth token from, as each token is valid for one connection
+    tokenURL: string;
     reconnect: number;
+    sessionID: string;
+    sessionToken: string;
 
-    constructor(term: Terminal, connectionFactory: ConnectionFactory, args: string, authToken: string) {
+    constructor(term: Terminal, connectionFactory: ConnectionFactory, args: string, authToken: string, tokenURL: string) {
         this.term = term;
         this.connectionFactory = connectionFactory;
         this.args = args;
         this.authToken = authToken;
+        this.tokenURL = tokenURL;
         this.reconnect = -1;
+        this.sessionID = "";
+        this.sessionToken = "";
     };
 
     open() {
         let connection = this.connectionFactory.create();
         let pingTimer: number;
         let reconnectTimeout: number;
+        let binary = false;
 
         const setup = () => {
             connection.onOpen(() => {
                 const termInfo = this.term.info();
+                binary = connection.protocol() == protocolBinary;
 
                 connection.send(JSON.stringify(
                     {
                         Arguments: this.args,
                         AuthToken: this.authToken,
+                        SessionToken: this.sessionToken,
                     }
                 ));
 
@@ -104,22 +119,34 @@ export class WebTTY {
                 const payload = data.slice(1);
                 switch (data[0]) {
                     case msgOutput:
-                        this.term.output(atob(payload));
+                        this.term.output(binary ? payload : atob(payload));
                         break;
                     case msgPong:
                         break;
                     case msgSetWindowTitle:
-                        this.term.setWindowTitle(payload);
+                        this.term.setWindowTitle(binary ? decodeUTF8(payload) : payload);
                         break;
                     case msgSetPreferences:
-                        const preferences = JSON.parse(payload);
+                        const preferences = JSON.parse(binary ? decodeUTF8(payload) : payload);
                         this.term.setPreferences(preferences);
                         break;
                     case msgSetReconnect:
-                        const autoReconnect = JSON.parse(payload);
+                        const autoReconnect = JSON.parse(binary ? decodeUTF8(payload) : payload);
                         console.log("Enabling reconnect: " + autoReconnect + " seconds")
                         this.reconnect = autoReconnect;
                         break;
+                    case msgSetSession:
+                        const session = JSON.parse(binary ? decodeUTF8(payload) : payload);
+                        this.sessionID = session.ID;
+                        // resume the session instead of creating a new one when reconnecting
+                        this.sessionToken = session.Token;
+                        console.log("Attached to session: " + session.ID + (session.ReadOnly ? " (read-only)" : ""));
+                        break;
+                    case msgSessionEvent:
+                        const event = JSON.parse(binary ? decodeUTF8(payload) : payload);
+                        const action = event.Type == "join" ? " joined" : " left";
+                        this.term.showMessage(event.RemoteAddr + action + ", viewers: " + event.Viewers, 2000);
+                        break;
                 }
             });
 
@@ -129,9 +156,19 @@ export class WebTTY {
                 this.term.showMessage("Connection Closed", 0);
                 if (this.reconnect > 0) {
                     reconnectTimeout = setTimeout(() => {
-                        connection = this.connectionFactory.create();
-                        this.term.reset();
-                        setup();
+                        fetch(this.tokenURL, { credentials: "same-origin" })
+                            .then((response) => response.json())
+                            .then((token) => {
+                                this.authToken = token.token;
+                            })
+                            .catch(() => {
+                                // resuming the session does not need a new token
+                            })
+                            .then(() => {
+                                connection = this.connectionFactory.create();
+                                this.term.reset();
+                                setup();
+                            });
                     }, this.reconnect * 1000);
                 }
             });
@@ -146,3 +183,13 @@ export class WebTTY {
         }
     };
 };
+
+// encodeUTF8 converts a string into a string of its UTF-8 bytes.
+export function encodeUTF8(data: string): string {
+    return unescape(encodeURIComponent(data));
+};
+
+// decodeUTF8 converts a string of UTF-8 bytes into a string.
+export function decodeUTF8(data: string): string {
+    return decodeURIComponent(escape(data));
+};
@@ -12,12 +12,23 @@ import (
 	"github.com/urfave/cli/v2"
 	"github.com/urfave/cli/v2/altsrc"
 
+	"github.com/labbs/webtty/audit"
 	"github.com/labbs/webtty/backend/localcommand"
+	"github.com/labbs/webtty/backend/replay"
+	"github.com/labbs/webtty/pkg/redact"
+	"github.com/labbs/webtty/recording"
 	"github.com/labbs/webtty/server"
 )
 
 var appOptions *server.Options = &server.Options{}
 var backendOptions *localcommand.Options = &localcommand.Options{}
+var recordingOptions *recording.Options = &recording.Options{}
+var replayOptions *replay.Options = &replay.Options{}
+var auditOptions *audit.Options = &audit.Options{}
+var redactDetectors, redactRules, blackList cli.StringSlice
+var recordingRedactDetectors, recordingRedactRules, recordingBlackList cli.StringSlice
+var tlsClientAllowedCNs, tlsClientAllowedOUs cli.StringSlice
+var oidcScopes, oidcAllowedEmails, oidcAllowedGroups cli.StringSlice
 var Version string = "unknown_version"
 var CommitID string = "unknown_commit"
 
@@ -31,37 +42,86 @@ func main() {
 	app.Flags = flags()
 	app.Before = altsrc.InitInputSourceWithContext(app.Flags, altsrc.NewJSONSourceFromFlagFunc("config"))
 	app.Action = action
+	app.Commands = []*cli.Command{
+		{
+			Name:      "replay",
+			Usage:     "Play back a recorded session as a read-only terminal with playback controls",
+			ArgsUsage: "<recording file>",
+			Flags:     replayFlags(),
+			Action:    replayAction,
+		},
+		{
+			Name:  "share",
+			Usage: "Manage share links of sessions of a running server with the admin API",
+			Flags: shareFlags(),
+			Subcommands: []*cli.Command{
+				{
+					Name:      "create",
+					Usage:     "Mint a share link of a session and print its URL",
+					ArgsUsage: "<session ID>",
+					Flags:     shareCreateFlags(),
+					Action:    shareCreateAction,
+				},
+				{
+					Name:   "list",
+					Usage:  "List share links not expired yet",
+					Action: shareListAction,
+				},
+				{
+					Name:      "revoke",
+					Usage:     "Revoke a share link",
+					ArgsUsage: "<share link ID>",
+					Action:    shareRevokeAction,
+				},
+			},
+		},
+	}
 	app.Run(os.Args)
 }
 
-func waitSignals(errs chan error, cancel context.CancelFunc, gracefullCancel context.CancelFunc) error {
+func waitSignals(errs chan error, cancel context.CancelFunc, gracefullCancel context.CancelFunc, reload func() error) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(
 		sigChan,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 	)
+	// SIGHUP keeps its default behavior unless something can be reloaded
+	if reload != nil {
+		signal.Notify(sigChan, syscall.SIGHUP)
+	}
 
-	select {
-	case err := <-errs:
-		return err
-
-	case s := <-sigChan:
-		switch s {
-		case syscall.SIGINT:
-			gracefullCancel()
-			fmt.Println("C-C to force close")
-			select {
-			case err := <-errs:
-				return err
-			case <-sigChan:
-				fmt.Println("Force closing...")
+	for {
+		select {
+		case err := <-errs:
+			return err
+
+		case s := <-sigChan:
+			switch s {
+			case syscall.SIGHUP:
+				if err := reload(); err != nil {
+					fmt.Printf("Failed to reload: %s\n", err)
+				}
+			case syscall.SIGINT:
+				gracefullCancel()
+				fmt.Println("C-C to force close")
+				for {
+					select {
+					case err := <-errs:
+						return err
+					case s := <-sigChan:
+						if s == syscall.SIGHUP {
+							continue
+						}
+						fmt.Println("Force closing...")
+						cancel()
+						return <-errs
+					}
+				}
+			default:
 				cancel()
 				return <-errs
 			}
-		default:
-			cancel()
-			return <-errs
 		}
 	}
 }
@@ -73,6 +133,29 @@ func action(c *cli.Context) error {
 		return fmt.Errorf(msg)
 	}
 
+	redactor, err := redact.New(&redact.Options{
+		Detectors: redactDetectors.Value(),
+		Rules:     redactRules.Value(),
+		Words:     blackList.Value(),
+	})
+	if err != nil {
+		return err
+	}
+	appOptions.Redactor = redactor
+
+	// recordings are redacted like live output unless configured separately
+	recordingOptions.Redactor = redactor
+	if c.IsSet("recording-redact-detector") || c.IsSet("recording-redact-rule") || c.IsSet("recording-blacklist") {
+		recordingOptions.Redactor, err = redact.New(&redact.Options{
+			Detectors: recordingRedactDetectors.Value(),
+			Rules:     recordingRedactRules.Value(),
+			Words:     recordingBlackList.Value(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	args := c.Args()
 	factory, err := localcommand.NewFactory(args.First(), args.Slice()[1:], backendOptions)
 	if err != nil {
@@ -86,6 +169,55 @@ func action(c *cli.Context) error {
 		"hostname": hostname,
 	}
 
+	recorder, err := recording.New(recordingOptions)
+	if err != nil {
+		return err
+	}
+	appOptions.Recorder = recorder
+
+	auditor, err := audit.New(auditOptions)
+	if err != nil {
+		return err
+	}
+	appOptions.Auditor = auditor
+
+	log.Printf("GoTTY is starting with command: %s", strings.Join(args.Slice(), " "))
+
+	return run(factory)
+}
+
+func replayAction(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		msg := "Error: No recording file given."
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf(msg)
+	}
+
+	file := c.Args().First()
+	factory, err := replay.NewFactory(file, replayOptions)
+	if err != nil {
+		return err
+	}
+
+	hostname, _ := os.Hostname()
+	appOptions.TitleVariables = map[string]interface{}{
+		"command":  "replay",
+		"argv":     []string{file},
+		"hostname": hostname,
+	}
+
+	log.Printf("GoTTY is starting to replay %s (%s)", file, factory.Duration())
+
+	return run(factory)
+}
+
+func run(factory server.Factory) error {
+	appOptions.TLSClientAllowedCNs = tlsClientAllowedCNs.Value()
+	appOptions.TLSClientAllowedOUs = tlsClientAllowedOUs.Value()
+	appOptions.OIDCScopes = oidcScopes.Value()
+	appOptions.OIDCAllowedEmails = oidcAllowedEmails.Value()
+	appOptions.OIDCAllowedGroups = oidcAllowedGroups.Value()
+
 	srv, err := server.New(factory, appOptions)
 	if err != nil {
 		return err
@@ -94,13 +226,15 @@ func action(c *cli.Context) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	gCtx, gCancel := context.WithCancel(context.Background())
 
-	log.Printf("GoTTY is starting with command: %s", strings.Join(args.Slice(), " "))
-
 	errs := make(chan error, 1)
 	go func() {
 		errs <- srv.Run(ctx, server.WithGracefullContext(gCtx))
 	}()
-	err = waitSignals(errs, cancel, gCancel)
+	var reload func() error
+	if appOptions.EnableTLS && appOptions.TLSReload {
+		reload = srv.ReloadCertificate
+	}
+	err = waitSignals(errs, cancel, gCancel, reload)
 
 	if err != nil && err != context.Canceled {
 		fmt.Printf("Error: %s\n", err)
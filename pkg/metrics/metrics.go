@@ -0,0 +1,229 @@
+// Package metrics provides counters, gauges and histograms
+// exposed in the Prometheus text format.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry is a set of metrics exposed together.
+type Registry struct {
+	metrics []metric
+	mutex   sync.Mutex
+}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+// NewRegistry creates a new instance of Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (registry *Registry) register(m metric) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.metrics = append(registry.metrics, m)
+}
+
+// WriteTo writes all the metrics in the Prometheus text format.
+func (registry *Registry) WriteTo(w io.Writer) (int64, error) {
+	registry.mutex.Lock()
+	metrics := append([]metric{}, registry.metrics...)
+	registry.mutex.Unlock()
+
+	buf := new(bytes.Buffer)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+	return buf.WriteTo(w)
+}
+
+// Handler returns an http.Handler exposing the metrics.
+func (registry *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registry.WriteTo(w)
+	})
+}
+
+// Counter is a value which only increases.
+type Counter struct {
+	name  string
+	help  string
+	value float64
+	mutex sync.Mutex
+}
+
+// NewCounter creates and registers a counter.
+func (registry *Registry) NewCounter(name string, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	registry.register(c)
+	return c
+}
+
+// Add increases the counter by delta.
+func (c *Counter) Add(delta float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.value += delta
+}
+
+// Inc increases the counter by one.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	writeSample(w, c.name, "", c.value)
+}
+
+// CounterVec is a set of counters distinguished by a label.
+type CounterVec struct {
+	name   string
+	help   string
+	label  string
+	values map[string]float64
+	mutex  sync.Mutex
+}
+
+// NewCounterVec creates and registers a set of counters.
+func (registry *Registry) NewCounterVec(name string, help string, label string) *CounterVec {
+	c := &CounterVec{name: name, help: help, label: label, values: map[string]float64{}}
+	registry.register(c)
+	return c
+}
+
+// Add increases the counter labeled value by delta.
+func (c *CounterVec) Add(value string, delta float64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.values[value] += delta
+}
+
+// Inc increases the counter labeled value by one.
+func (c *CounterVec) Inc(value string) {
+	c.Add(value, 1)
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	writeHeader(w, c.name, c.help, "counter")
+	values := make([]string, 0, len(c.values))
+	for value := range c.values {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	for _, value := range values {
+		writeSample(w, c.name, labels(c.label, value), c.values[value])
+	}
+}
+
+// GaugeFunc is a value which can go up and down, obtained when exposed.
+type GaugeFunc struct {
+	name string
+	help string
+	fn   func() float64
+}
+
+// NewGaugeFunc creates and registers a gauge with the value returned by fn.
+func (registry *Registry) NewGaugeFunc(name string, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, fn: fn}
+	registry.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	writeSample(w, g.name, "", g.fn())
+}
+
+// Histogram counts observed values in buckets.
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+	mutex   sync.Mutex
+}
+
+// NewHistogram creates and registers a histogram
+// with the upper bounds of buckets in increasing order.
+func (registry *Registry) NewHistogram(name string, help string, buckets []float64) *Histogram {
+	h := &Histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+	registry.register(h)
+	return h
+}
+
+// Observe adds a value to the histogram.
+func (h *Histogram) Observe(value float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for i, bound := range h.buckets {
+		writeSample(w, h.name+"_bucket", labels("le", formatValue(bound)), float64(h.counts[i]))
+	}
+	writeSample(w, h.name+"_bucket", labels("le", "+Inf"), float64(h.count))
+	writeSample(w, h.name+"_sum", "", h.sum)
+	writeSample(w, h.name+"_count", "", float64(h.count))
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func writeHeader(w io.Writer, name string, help string, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, helpEscaper.Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+func writeSample(w io.Writer, name string, labels string, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(value))
+}
+
+func labels(name string, value string) string {
+	return fmt.Sprintf(`{%s="%s"}`, name, labelEscaper.Replace(value))
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
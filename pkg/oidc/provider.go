@@ -0,0 +1,212 @@
+// Package oidc implements the relying party of the OpenID Connect
+// authorization code flow with the standard library.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTimeout is the timeout of requests to the issuer with the default client.
+const DefaultTimeout = 10 * time.Second
+
+// Config is the registration of the client at the issuer.
+type Config struct {
+	// Issuer is the issuer identifier URL, where the discovery document is served under
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Scopes are requested in addition to openid
+	Scopes []string
+}
+
+// metadata is the part of the discovery document used by Provider.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to an OpenID Connect issuer.
+// The discovery document is fetched on first use and cached.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mutex    sync.Mutex
+	metadata *metadata
+	keys     *keySet
+}
+
+// NewProvider creates a new instance of Provider.
+// A client with DefaultTimeout is used if client is nil.
+func NewProvider(config Config, client *http.Client) (*Provider, error) {
+	if config.Issuer == "" {
+		return nil, errors.New("issuer is not given")
+	}
+	if config.ClientID == "" {
+		return nil, errors.New("client ID is not given")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Provider{
+		config: config,
+		client: client,
+	}, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	md := &metadata{}
+	if err := p.getJSON(ctx, wellKnown, md); err != nil {
+		return nil, errors.Wrapf(err, "failed to discover the issuer")
+	}
+	if md.Issuer != p.config.Issuer {
+		return nil, errors.Errorf("issuer of the discovery document `%s` does not match `%s`", md.Issuer, p.config.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("discovery document lacks endpoints")
+	}
+	p.metadata = md
+	p.keys = newKeySet(md.JWKSURI, p)
+	return md, nil
+}
+
+// AuthCodeURL returns the URL of the authorization endpoint to send users to.
+// The code verifier of PKCE is bound to the request with its S256 challenge.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURL, state, nonce, codeVerifier string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(md.AuthorizationEndpoint)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse the authorization endpoint")
+	}
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", redirectURL)
+	query.Set("scope", strings.Join(append([]string{"openid"}, p.config.Scopes...), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Exchange redeems code at the token endpoint and returns the verified claims of the ID token.
+func (p *Provider) Exchange(ctx context.Context, redirectURL, code, codeVerifier, nonce string) (Claims, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequestWithContext(ctx, "POST", md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to request tokens")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the token response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("token endpoint responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the token response")
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response lacks an ID token")
+	}
+	return p.Verify(ctx, tokens.IDToken, nonce)
+}
+
+// Verify verifies the signature and the standard claims of the ID token.
+func (p *Provider) Verify(ctx context.Context, idToken string, nonce string) (Claims, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := verifyJWT(ctx, idToken, p.keys)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to verify the ID token")
+	}
+	if iss := claims.String("iss"); iss != md.Issuer {
+		return nil, errors.Errorf("ID token is issued by `%s`", iss)
+	}
+	if !claims.hasAudience(p.config.ClientID) {
+		return nil, errors.New("ID token is not issued for the client")
+	}
+	now := time.Now()
+	exp, ok := claims.time("exp")
+	if !ok || !now.Before(exp.Add(clockSkew)) {
+		return nil, errors.New("ID token expired")
+	}
+	if iat, ok := claims.time("iat"); ok && now.Add(clockSkew).Before(iat) {
+		return nil, errors.New("ID token is issued in the future")
+	}
+	if claims.String("nonce") != nonce {
+		return nil, errors.New("ID token nonce mismatch")
+	}
+	return claims, nil
+}
+
+// clockSkew is tolerated between the issuer and the client.
+const clockSkew = time.Minute
+
+func (p *Provider) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("%s responded %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,386 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID     = "webtty"
+	testClientSecret = "secret"
+	testRedirectURL  = "https://webtty.example.com/oidc/callback"
+	testKeyID        = "key-1"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("GenerateKey() = %v", err)
+		}
+		testKey = key
+	})
+	return testKey
+}
+
+// testGrant is an authorization request of the test issuer.
+type testGrant struct {
+	redirectURL string
+	challenge   string
+	nonce       string
+}
+
+// testIssuer is an OpenID Connect issuer serving discovery, the key set,
+// the authorization endpoint granting codes at once and the token endpoint.
+type testIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mutex  sync.Mutex
+	grants map[string]testGrant
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	ti := &testIssuer{
+		key:    testRSAKey(t),
+		grants: map[string]testGrant{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 ti.URL,
+			"authorization_endpoint": ti.URL + "/authorize",
+			"token_endpoint":         ti.URL + "/token",
+			"jwks_uri":               ti.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": testKeyID,
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(ti.key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(ti.key.E)).Bytes()),
+				},
+				// keys for encryption are not used to verify
+				{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+			},
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("client_id") != testClientID || query.Get("code_challenge_method") != "S256" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		code := base64.RawURLEncoding.EncodeToString([]byte(query.Get("state")))
+		ti.mutex.Lock()
+		ti.grants[code] = testGrant{
+			redirectURL: query.Get("redirect_uri"),
+			challenge:   query.Get("code_challenge"),
+			nonce:       query.Get("nonce"),
+		}
+		ti.mutex.Unlock()
+		http.Redirect(w, r, query.Get("redirect_uri")+"?"+url.Values{
+			"code":  {code},
+			"state": {query.Get("state")},
+		}.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != testClientID || secret != testClientSecret {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		ti.mutex.Lock()
+		grant, ok := ti.grants[r.PostFormValue("code")]
+		delete(ti.grants, r.PostFormValue("code"))
+		ti.mutex.Unlock()
+
+		challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+			r.PostFormValue("redirect_uri") != grant.redirectURL ||
+			base64.RawURLEncoding.EncodeToString(challenge[:]) != grant.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token":     ti.sign(t, testHeader(), ti.claims(grant.nonce)),
+		})
+	})
+
+	ti.Server = httptest.NewServer(mux)
+	t.Cleanup(ti.Close)
+	return ti
+}
+
+func testHeader() map[string]interface{} {
+	return map[string]interface{}{"alg": "RS256", "kid": testKeyID, "typ": "JWT"}
+}
+
+// claims returns valid claims of an ID token with nonce.
+func (ti *testIssuer) claims(nonce string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":   ti.URL,
+		"aud":   testClientID,
+		"sub":   "alice",
+		"exp":   now.Add(time.Hour).Unix(),
+		"iat":   now.Unix(),
+		"nonce": nonce,
+	}
+}
+
+// sign returns a JWT signed with the key of the issuer with RS256,
+// whatever the algorithm in the header is.
+func (ti *testIssuer) sign(t *testing.T, header, claims map[string]interface{}) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal() = %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	input := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, ti.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("SignPKCS1v15() = %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (ti *testIssuer) provider(t *testing.T) *Provider {
+	t.Helper()
+	p, err := NewProvider(Config{
+		Issuer:       ti.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		Scopes:       []string{"email"},
+	}, ti.Client())
+	if err != nil {
+		t.Fatalf("NewProvider() = %v", err)
+	}
+	return p
+}
+
+// authorize sends the user agent to the authorization URL and returns the code granted.
+func (ti *testIssuer) authorize(t *testing.T, authURL string, state string) string {
+	t.Helper()
+	client := ti.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("failed to authorize: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("authorization endpoint responded %s", resp.Status)
+	}
+	if got := location.Query().Get("state"); got != state {
+		t.Fatalf("state = %q, want %q", got, state)
+	}
+	return location.Query().Get("code")
+}
+
+func TestProviderAuthorizationCodeFlow(t *testing.T) {
+	ti := newTestIssuer(t)
+	p := ti.provider(t)
+	ctx := context.Background()
+
+	authURL, err := p.AuthCodeURL(ctx, testRedirectURL, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() = %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	query := u.Query()
+	challenge := sha256.Sum256([]byte("verifier-1"))
+	for name, want := range map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(challenge[:]),
+		"code_challenge_method": "S256",
+	} {
+		if got := query.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	code := ti.authorize(t, authURL, "state-1")
+	claims, err := p.Exchange(ctx, testRedirectURL, code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() = %v", err)
+	}
+	if sub := claims.String("sub"); sub != "alice" {
+		t.Errorf("sub = %q, want alice", sub)
+	}
+}
+
+func TestProviderExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		verifier string
+		nonce    string
+	}{
+		{name: "code verifier mismatch", verifier: "verifier-2", nonce: "nonce-1"},
+		{name: "nonce mismatch", verifier: "verifier-1", nonce: "nonce-2"},
+		{name: "unknown code", code: "unknown", verifier: "verifier-1", nonce: "nonce-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ti := newTestIssuer(t)
+			p := ti.provider(t)
+			ctx := context.Background()
+
+			authURL, err := p.AuthCodeURL(ctx, testRedirectURL, "state-1", "nonce-1", "verifier-1")
+			if err != nil {
+				t.Fatalf("AuthCodeURL() = %v", err)
+			}
+			code := ti.authorize(t, authURL, "state-1")
+			if tt.code != "" {
+				code = tt.code
+			}
+			if _, err := p.Exchange(ctx, testRedirectURL, code, tt.verifier, tt.nonce); err == nil {
+				t.Errorf("Exchange() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestProviderVerify(t *testing.T) {
+	ti := newTestIssuer(t)
+	p := ti.provider(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		header  func(h map[string]interface{})
+		claims  func(c map[string]interface{})
+		token   func(raw string) string
+		wantErr bool
+	}{
+		{name: "valid"},
+		{name: "audience in array", claims: func(c map[string]interface{}) { c["aud"] = []string{"other", testClientID} }},
+		{name: "expired within clock skew", claims: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-clockSkew / 2).Unix() }},
+		{name: "other issuer", claims: func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" }, wantErr: true},
+		{name: "other audience", claims: func(c map[string]interface{}) { c["aud"] = "other" }, wantErr: true},
+		{name: "expired", claims: func(c map[string]interface{}) { c["exp"] = time.Now().Add(-2 * clockSkew).Unix() }, wantErr: true},
+		{name: "no expiry", claims: func(c map[string]interface{}) { delete(c, "exp") }, wantErr: true},
+		{name: "issued in the future", claims: func(c map[string]interface{}) { c["iat"] = time.Now().Add(2 * clockSkew).Unix() }, wantErr: true},
+		{name: "nonce mismatch", claims: func(c map[string]interface{}) { c["nonce"] = "other" }, wantErr: true},
+		{name: "algorithm none", header: func(h map[string]interface{}) { h["alg"] = "none" }, wantErr: true},
+		{name: "symmetric algorithm", header: func(h map[string]interface{}) { h["alg"] = "HS256" }, wantErr: true},
+		{name: "algorithm of other keys", header: func(h map[string]interface{}) { h["alg"] = "ES256" }, wantErr: true},
+		{name: "unknown key", header: func(h map[string]interface{}) { h["kid"] = "key-2" }, wantErr: true},
+		{name: "key for encryption", header: func(h map[string]interface{}) { h["kid"] = "enc" }, wantErr: true},
+		{
+			name: "tampered claims",
+			token: func(raw string) string {
+				parts := strings.Split(raw, ".")
+				claims, _ := json.Marshal(map[string]interface{}{"iss": ti.URL, "aud": testClientID, "sub": "mallory", "exp": time.Now().Add(time.Hour).Unix(), "nonce": "nonce-1"})
+				return parts[0] + "." + base64.RawURLEncoding.EncodeToString(claims) + "." + parts[2]
+			},
+			wantErr: true,
+		},
+		{
+			name: "signed with other key",
+			token: func(raw string) string {
+				parts := strings.Split(raw, ".")
+				digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+				sig, _ := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+				return parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString(sig)
+			},
+			wantErr: true,
+		},
+		{name: "malformed", token: func(string) string { return "a.b" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, claims := testHeader(), ti.claims("nonce-1")
+			if tt.header != nil {
+				tt.header(header)
+			}
+			if tt.claims != nil {
+				tt.claims(claims)
+			}
+			raw := ti.sign(t, header, claims)
+			if tt.token != nil {
+				raw = tt.token(raw)
+			}
+
+			_, err := p.Verify(context.Background(), raw, "nonce-1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProviderDiscoveryIssuerMismatch(t *testing.T) {
+	ti := newTestIssuer(t)
+	p, err := NewProvider(Config{Issuer: ti.URL + "/other", ClientID: testClientID}, ti.Client())
+	if err != nil {
+		t.Fatalf("NewProvider() = %v", err)
+	}
+	if _, err := p.AuthCodeURL(context.Background(), testRedirectURL, "s", "n", "v"); err == nil {
+		t.Errorf("AuthCodeURL() succeeded with a mismatching issuer")
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if _, err := NewProvider(Config{ClientID: testClientID}, nil); err == nil {
+		t.Errorf("NewProvider() without issuer succeeded")
+	}
+	if _, err := NewProvider(Config{Issuer: "https://issuer.example.com"}, nil); err == nil {
+		t.Errorf("NewProvider() without client ID succeeded")
+	}
+
+	p, err := NewProvider(Config{Issuer: "https://issuer.example.com", ClientID: testClientID}, nil)
+	if err != nil {
+		t.Fatalf("NewProvider() = %v", err)
+	}
+	if p.client == http.DefaultClient || p.client.Timeout != DefaultTimeout {
+		t.Errorf("default client has timeout %s, want %s", p.client.Timeout, DefaultTimeout)
+	}
+}
@@ -0,0 +1,165 @@
+// Package policy decides which commands, arguments and URL parameters
+// clients are allowed to use, with rules for users, roles and groups.
+//
+// A policy is a JSON file with an ordered list of rules. The first rule
+// matching the client decides, and clients matching no rule are denied.
+//
+//	{
+//	  "rules": [
+//	    {"roles": ["operator"], "commands": ["bash"], "args": ["-[a-z]+"], "params": ["arg", "session"]},
+//	    {"users": ["alice"], "commands": ["*"]},
+//	    {"params": []}
+//	  ]
+//	}
+//
+// A rule matches clients listed in any of users, roles and groups, or every
+// client if none of them is given. Omitted commands, args and params are not
+// restricted, while empty lists allow nothing. commands are matched with the
+// command or its base name, * allows any command. args are regular expressions
+// matching whole arguments. params are names of URL parameters.
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// Policy is an ordered list of rules.
+type Policy struct {
+	rules []*rule
+}
+
+type rule struct {
+	Users    []string  `json:"users"`
+	Roles    []string  `json:"roles"`
+	Groups   []string  `json:"groups"`
+	Commands []string  `json:"commands"`
+	Args     *[]string `json:"args"`
+	Params   *[]string `json:"params"`
+
+	args []*regexp.Regexp
+}
+
+// Request is what a client requests.
+type Request struct {
+	User   string
+	Role   string
+	Groups []string
+	// Command and Args are checked if Command is not empty
+	Command string
+	Args    []string
+	// Params are the names of the URL parameters
+	Params []string
+}
+
+// Denial is the error of a request not allowed by the policy.
+type Denial struct {
+	Reason string
+}
+
+func (d *Denial) Error() string {
+	return "denied by policy: " + d.Reason
+}
+
+// Load reads the policy from the JSON file at path.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read policy file")
+	}
+	var file struct {
+		Rules []*rule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse policy file `%s`", path)
+	}
+
+	for i, r := range file.Rules {
+		if r.Args == nil {
+			continue
+		}
+		for _, pattern := range *r.Args {
+			re, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid argument pattern of rule %d in policy file", i+1)
+			}
+			r.args = append(r.args, re)
+		}
+	}
+	return &Policy{rules: file.Rules}, nil
+}
+
+// Check returns a *Denial if req is not allowed.
+func (p *Policy) Check(req Request) error {
+	for _, r := range p.rules {
+		if r.matches(req) {
+			return r.check(req)
+		}
+	}
+	return &Denial{Reason: "no rule for the user"}
+}
+
+func (r *rule) matches(req Request) bool {
+	if len(r.Users) == 0 && len(r.Roles) == 0 && len(r.Groups) == 0 {
+		return true
+	}
+	if req.User != "" && contains(r.Users, req.User) {
+		return true
+	}
+	if req.Role != "" && contains(r.Roles, req.Role) {
+		return true
+	}
+	for _, group := range req.Groups {
+		if contains(r.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rule) check(req Request) error {
+	if r.Params != nil {
+		for _, param := range req.Params {
+			if !contains(*r.Params, param) {
+				return &Denial{Reason: "URL parameter `" + param + "` is not allowed"}
+			}
+		}
+	}
+	if req.Command == "" {
+		return nil
+	}
+	if r.Commands != nil && !contains(r.Commands, "*") &&
+		!contains(r.Commands, req.Command) && !contains(r.Commands, filepath.Base(req.Command)) {
+		return &Denial{Reason: "command `" + req.Command + "` is not allowed"}
+	}
+	if r.Args != nil {
+		for _, arg := range req.Args {
+			if !matchesAny(r.args, arg) {
+				return &Denial{Reason: "argument `" + arg + "` is not allowed"}
+			}
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPolicy = `{
+  "rules": [
+    {"users": ["alice"], "commands": ["*"]},
+    {"roles": ["operator"], "commands": ["bash", "/usr/bin/top"], "args": ["-[a-z]+", "--login"], "params": ["arg", "session"]},
+    {"groups": ["ops"], "commands": ["htop"], "args": []},
+    {"params": []}
+  ]
+}`
+
+func loadTestPolicy(t *testing.T, content string) (*Policy, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile() = %v", err)
+	}
+	return Load(path)
+}
+
+func TestCheck(t *testing.T) {
+	p, err := loadTestPolicy(t, testPolicy)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		req     Request
+		allowed bool
+	}{
+		{name: "any command of the user", req: Request{User: "alice", Command: "vim", Args: []string{"/etc/passwd"}, Params: []string{"x"}}, allowed: true},
+		{name: "the user is matched before the role", req: Request{User: "alice", Role: "operator", Command: "vim"}, allowed: true},
+		{name: "command of the role", req: Request{User: "bob", Role: "operator", Command: "bash", Args: []string{"-l", "--login"}}, allowed: true},
+		{name: "base name of the command", req: Request{Role: "operator", Command: "/bin/bash"}, allowed: true},
+		{name: "full path of the command", req: Request{Role: "operator", Command: "/usr/bin/top"}, allowed: true},
+		{name: "other command of the role", req: Request{Role: "operator", Command: "vim"}},
+		{name: "other path of the command", req: Request{Role: "operator", Command: "/bin/top"}},
+		{name: "argument matching a pattern", req: Request{Role: "operator", Command: "bash", Args: []string{"-c"}}, allowed: true},
+		{name: "argument matching partially", req: Request{Role: "operator", Command: "bash", Args: []string{"-c rm"}}},
+		{name: "argument matching no pattern", req: Request{Role: "operator", Command: "bash", Args: []string{"script.sh"}}},
+		{name: "allowed parameters", req: Request{Role: "operator", Params: []string{"arg", "session"}}, allowed: true},
+		{name: "other parameter", req: Request{Role: "operator", Params: []string{"speed"}}},
+		{name: "command of the group", req: Request{Groups: []string{"dev", "ops"}, Command: "htop"}, allowed: true},
+		{name: "no argument allowed", req: Request{Groups: []string{"ops"}, Command: "htop", Args: []string{"-d"}}},
+		{name: "any command of the last rule", req: Request{User: "mallory", Command: "sh", Args: []string{"-c", "id"}}, allowed: true},
+		{name: "no parameter allowed by the last rule", req: Request{User: "mallory", Params: []string{"arg"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(tt.req)
+			if (err == nil) != tt.allowed {
+				t.Errorf("Check() = %v, want allowed %t", err, tt.allowed)
+			}
+			if _, ok := err.(*Denial); err != nil && !ok {
+				t.Errorf("Check() = %T, want *Denial", err)
+			}
+		})
+	}
+}
+
+func TestCheckWithoutMatchingRule(t *testing.T) {
+	p, err := loadTestPolicy(t, `{"rules": [{"users": ["alice"]}]}`)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if err := p.Check(Request{User: "alice", Command: "bash", Params: []string{"arg"}}); err != nil {
+		t.Errorf("Check() = %v for a rule without restrictions", err)
+	}
+	if err := p.Check(Request{User: "bob"}); err == nil {
+		t.Errorf("Check() allowed a user matching no rule")
+	}
+	if err := p.Check(Request{}); err == nil {
+		t.Errorf("Check() allowed an anonymous client matching no rule")
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	for name, content := range map[string]string{
+		"malformed JSON":   `{"rules": [`,
+		"invalid pattern":  `{"rules": [{"args": ["("]}]}`,
+		"rules not a list": `{"rules": {}}`,
+	} {
+		if _, err := loadTestPolicy(t, content); err == nil {
+			t.Errorf("Load() with %s succeeded", name)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("Load() of a missing file succeeded")
+	}
+}
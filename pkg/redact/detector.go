@@ -0,0 +1,190 @@
+package redact
+
+import (
+	"math"
+	"regexp"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Detector finds secrets in data.
+type Detector interface {
+	// Name returns the name of the detector.
+	Name() string
+	// FindAll returns the [start, end) byte ranges of secrets in data.
+	FindAll(data []byte) [][2]int
+}
+
+// Holder is implemented by detectors finding secrets which span lines.
+// Stream holds back output from the position returned by Hold,
+// so that a secret is not emitted before the end of it is seen.
+type Holder interface {
+	// Hold returns the start of an unfinished secret at the end of data, or -1.
+	Hold(data []byte) int
+}
+
+// secretGroup is the name of the capture group to mask in a pattern.
+// When a pattern has no such group, whole matches are masked.
+const secretGroup = "secret"
+
+// RegexDetector detects secrets matching a regular expression.
+type RegexDetector struct {
+	name    string
+	pattern *regexp.Regexp
+	group   int
+	keep    int
+	// pattern of the beginning of secrets spanning lines, if any
+	opening *regexp.Regexp
+}
+
+// NewRegexDetector creates a new instance of RegexDetector.
+// keep is the number of leading characters of secrets left visible.
+func NewRegexDetector(name string, pattern string, keep int) (*RegexDetector, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compile pattern of redaction rule `%s`", name)
+	}
+
+	group := 0
+	if i := re.SubexpIndex(secretGroup); i > 0 {
+		group = i
+	}
+
+	return &RegexDetector{
+		name:    name,
+		pattern: re,
+		group:   group,
+		keep:    keep,
+	}, nil
+}
+
+func (detector *RegexDetector) Name() string {
+	return detector.name
+}
+
+func (detector *RegexDetector) FindAll(data []byte) [][2]int {
+	var ranges [][2]int
+	for _, match := range detector.pattern.FindAllSubmatchIndex(data, -1) {
+		start, end := match[2*detector.group], match[2*detector.group+1]
+		if start < 0 {
+			continue
+		}
+		start = skipRunes(data, start, end, detector.keep)
+		if start < end {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+	return ranges
+}
+
+func (detector *RegexDetector) Hold(data []byte) int {
+	if detector.opening == nil {
+		return -1
+	}
+
+	matches := detector.opening.FindAllIndex(data, -1)
+	if len(matches) == 0 {
+		return -1
+	}
+	start := matches[len(matches)-1][0]
+	if detector.pattern.Match(data[start:]) {
+		return -1
+	}
+	return start
+}
+
+// EntropyDetector detects long tokens whose characters look random,
+// such as API keys and passwords generated by machines.
+type EntropyDetector struct {
+	minLength int
+	threshold float64
+	keep      int
+}
+
+var tokenPattern = regexp.MustCompile(`[A-Za-z0-9+/=_\-]+`)
+
+// NewEntropyDetector creates a new instance of EntropyDetector.
+// Tokens of at least minLength characters with the Shannon entropy
+// per character above threshold are detected.
+func NewEntropyDetector(minLength int, threshold float64, keep int) *EntropyDetector {
+	return &EntropyDetector{
+		minLength: minLength,
+		threshold: threshold,
+		keep:      keep,
+	}
+}
+
+func (detector *EntropyDetector) Name() string {
+	return "high-entropy"
+}
+
+func (detector *EntropyDetector) FindAll(data []byte) [][2]int {
+	var ranges [][2]int
+	for _, match := range tokenPattern.FindAllIndex(data, -1) {
+		start, end := match[0], match[1]
+		if end-start < detector.minLength || entropy(data[start:end]) < detector.threshold {
+			continue
+		}
+		start = skipRunes(data, start, end, detector.keep)
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// entropy returns the Shannon entropy of data in bits per byte.
+func entropy(data []byte) float64 {
+	counts := map[byte]int{}
+	for _, b := range data {
+		counts[b]++
+	}
+
+	var result float64
+	for _, count := range counts {
+		p := float64(count) / float64(len(data))
+		result -= p * math.Log2(p)
+	}
+	return result
+}
+
+// builtinDetectors are the detectors which can be enabled by their names.
+var builtinDetectors = map[string]func() Detector{
+	"aws-access-key": func() Detector {
+		return mustRegexDetector("aws-access-key", `\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`, 4)
+	},
+	"aws-secret-key": func() Detector {
+		return mustRegexDetector("aws-secret-key", `(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})\b`, 0)
+	},
+	"jwt": func() Detector {
+		return mustRegexDetector("jwt", `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`, 4)
+	},
+	"private-key": func() Detector {
+		detector := mustRegexDetector("private-key", `-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----(?P<secret>[\s\S]*?)-----END [A-Z0-9 ]*PRIVATE KEY-----`, 0)
+		detector.opening = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----`)
+		return detector
+	},
+	"github-token": func() Detector {
+		return mustRegexDetector("github-token", `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`, 4)
+	},
+	"high-entropy": func() Detector {
+		return NewEntropyDetector(20, 4.3, 4)
+	},
+}
+
+// BuiltinDetectorNames returns the names of the builtin detectors.
+func BuiltinDetectorNames() []string {
+	names := make([]string, 0, len(builtinDetectors))
+	for name := range builtinDetectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func mustRegexDetector(name string, pattern string, keep int) *RegexDetector {
+	detector, err := NewRegexDetector(name, pattern, keep)
+	if err != nil {
+		panic(err)
+	}
+	return detector
+}
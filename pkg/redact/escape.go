@@ -0,0 +1,122 @@
+package redact
+
+import (
+	"unicode"
+)
+
+// states of the parser of escape sequences
+const (
+	stateGround = iota
+	stateEscape
+	stateCSI
+	// OSC, DCS, SOS, PM and APC terminated by BEL or ST
+	stateString
+	stateStringEscape
+)
+
+// text is the visible text of output, excluding escape sequences.
+type text struct {
+	visible []byte
+	// offsets in the output of each byte in visible
+	offsets []int
+	// states of the parser before each byte of the output and at the end
+	states []int
+}
+
+// scan extracts the visible text from data starting with the parser in state.
+func scan(data []byte, state int) *text {
+	t := &text{
+		visible: make([]byte, 0, len(data)),
+		offsets: make([]int, 0, len(data)),
+		states:  make([]int, len(data)+1),
+	}
+
+	for i, b := range data {
+		t.states[i] = state
+		if state == stateGround && b != 0x1b {
+			t.visible = append(t.visible, b)
+			t.offsets = append(t.offsets, i)
+			continue
+		}
+		state = nextState(state, b)
+	}
+	t.states[len(data)] = state
+
+	return t
+}
+
+func nextState(state int, b byte) int {
+	switch state {
+	case stateGround:
+		if b == 0x1b {
+			return stateEscape
+		}
+		return stateGround
+	case stateEscape:
+		switch {
+		case b == '[':
+			return stateCSI
+		case b == ']' || b == 'P' || b == 'X' || b == '^' || b == '_':
+			return stateString
+		case b == 0x1b || (b >= 0x20 && b <= 0x2f):
+			// intermediate bytes
+			return stateEscape
+		}
+		return stateGround
+	case stateCSI:
+		switch {
+		case b == 0x1b:
+			return stateEscape
+		case b >= 0x40 && b <= 0x7e:
+			return stateGround
+		}
+		return stateCSI
+	case stateString:
+		switch b {
+		case 0x07:
+			return stateGround
+		case 0x1b:
+			return stateStringEscape
+		}
+		return stateString
+	case stateStringEscape:
+		if b == '\\' {
+			return stateGround
+		}
+		return nextState(stateEscape, b)
+	}
+	return stateGround
+}
+
+// wideRanges are the ranges of characters occupying two columns on terminals.
+var wideRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x33ff, Stride: 1},
+		{Lo: 0x3400, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0x9fff, Stride: 1},
+		{Lo: 0xa000, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe4f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f900, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// runeWidth returns the number of columns c occupies on terminals.
+func runeWidth(c rune) int {
+	switch {
+	case unicode.In(c, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.Is(wideRanges, c):
+		return 2
+	}
+	return 1
+}
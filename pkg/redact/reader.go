@@ -0,0 +1,103 @@
+package redact
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultFlushDelay is the default duration Reader waits for
+// more output before emitting output held back.
+const DefaultFlushDelay = 20 * time.Millisecond
+
+// Reader is an io.Reader redacting output of another reader with a Stream.
+// Output held back by the stream is emitted when the underlying reader
+// produces nothing for a while, so that interactive programs stay responsive,
+// except secrets spanning lines which are not finished yet.
+type Reader struct {
+	reader     io.Reader
+	stream     *Stream
+	flushDelay time.Duration
+
+	output  chan []byte
+	pending []byte
+	err     error
+
+	timer  *time.Timer
+	closed bool
+	mutex  sync.Mutex
+}
+
+// NewReader creates a new instance of Reader and starts reading reader.
+func NewReader(reader io.Reader, redactor *Redactor) *Reader {
+	r := &Reader{
+		reader:     reader,
+		stream:     NewStream(redactor, DefaultWindow),
+		flushDelay: DefaultFlushDelay,
+
+		output: make(chan []byte, 64),
+	}
+	r.timer = time.AfterFunc(r.flushDelay, r.flush)
+	r.timer.Stop()
+
+	go r.run()
+
+	return r
+}
+
+func (r *Reader) Read(p []byte) (n int, err error) {
+	if len(r.pending) == 0 {
+		data, ok := <-r.output
+		if !ok {
+			return 0, r.err
+		}
+		r.pending = data
+	}
+
+	n = copy(p, r.pending)
+	r.pending = r.pending[n:]
+
+	return n, nil
+}
+
+func (r *Reader) run() {
+	buffer := make([]byte, 4096)
+	for {
+		n, err := r.reader.Read(buffer)
+		if n > 0 {
+			r.mutex.Lock()
+			r.timer.Stop()
+			r.send(r.stream.Push(buffer[:n]))
+			if r.stream.Buffered() > 0 {
+				r.timer.Reset(r.flushDelay)
+			}
+			r.mutex.Unlock()
+		}
+		if err != nil {
+			r.mutex.Lock()
+			r.timer.Stop()
+			r.send(r.stream.Flush())
+			r.err = err
+			r.closed = true
+			close(r.output)
+			r.mutex.Unlock()
+			return
+		}
+	}
+}
+
+func (r *Reader) flush() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.closed {
+		// unfinished secrets are kept until more output comes
+		r.send(r.stream.Idle())
+	}
+}
+
+func (r *Reader) send(data []byte) {
+	if len(data) > 0 {
+		r.output <- data
+	}
+}
@@ -0,0 +1,194 @@
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Mask is the character replacing each character of secrets.
+const Mask = '*'
+
+// Redactor masks secrets found by its detectors.
+// Each masked character is replaced with a single Mask,
+// so that the layout of lines is preserved.
+type Redactor struct {
+	detectors []Detector
+}
+
+// Options configures the redactor built by New.
+type Options struct {
+	// Names of builtin detectors, `all` enables all of them
+	// and `none` disables redaction regardless of the other options
+	Detectors []string
+	// Custom rules formatted as `{name}={regular expression}`.
+	// Only the capture group named `secret` is masked when it exists.
+	Rules []string
+	// Words masked wherever they appear
+	Words []string
+}
+
+// NewRedactor creates a new instance of Redactor.
+func NewRedactor(detectors ...Detector) *Redactor {
+	return &Redactor{detectors: detectors}
+}
+
+// New builds a redactor from options.
+// It returns nil when no detector is configured.
+func New(options *Options) (*Redactor, error) {
+	detectors := []Detector{}
+
+	for _, name := range options.Detectors {
+		if name == "none" {
+			return nil, nil
+		}
+	}
+	for _, name := range options.Detectors {
+		if name == "all" {
+			for _, name := range BuiltinDetectorNames() {
+				detectors = append(detectors, builtinDetectors[name]())
+			}
+			continue
+		}
+		newDetector, ok := builtinDetectors[name]
+		if !ok {
+			return nil, errors.Errorf("unknown redaction detector `%s`, available: %s", name, strings.Join(BuiltinDetectorNames(), ", "))
+		}
+		detectors = append(detectors, newDetector())
+	}
+
+	for _, rule := range options.Rules {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("malformed redaction rule `%s`, expected `{name}={regular expression}`", rule)
+		}
+		detector, err := NewRegexDetector(parts[0], parts[1], 0)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, detector)
+	}
+
+	words := []string{}
+	for _, word := range options.Words {
+		if word != "" {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+	}
+	if len(words) > 0 {
+		detector, err := NewRegexDetector("blacklist", strings.Join(words, "|"), 0)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, detector)
+	}
+
+	if len(detectors) == 0 {
+		return nil, nil
+	}
+
+	return NewRedactor(detectors...), nil
+}
+
+// Redact returns data with secrets masked.
+// Data is supposed to start outside of escape sequences,
+// which are left intact. The result is never longer than data.
+func (redactor *Redactor) Redact(data []byte) []byte {
+	text := scan(data, stateGround)
+	ranges := redactor.find(text.visible)
+	if len(ranges) == 0 {
+		return data
+	}
+
+	return mask(data, text, ranges)
+}
+
+// find returns the merged ranges of secrets found by all the detectors.
+func (redactor *Redactor) find(data []byte) [][2]int {
+	var ranges [][2]int
+	for _, detector := range redactor.detectors {
+		ranges = append(ranges, detector.FindAll(data)...)
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0] < ranges[j][0]
+	})
+	merged := [][2]int{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r[0] <= last[1] {
+			if r[1] > last[1] {
+				last[1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// hold returns the start of an unfinished secret at the end of data, or -1.
+func (redactor *Redactor) hold(data []byte) int {
+	start := -1
+	for _, detector := range redactor.detectors {
+		holder, ok := detector.(Holder)
+		if !ok {
+			continue
+		}
+		if i := holder.Hold(data); i >= 0 && (start < 0 || i < start) {
+			start = i
+		}
+	}
+	return start
+}
+
+// mask replaces characters of data in ranges of the visible text with Mask.
+// Each character is replaced with as many masks as the columns it occupies,
+// and control characters including line breaks are kept.
+func mask(data []byte, text *text, ranges [][2]int) []byte {
+	masked := make([]bool, len(data))
+	for _, r := range ranges {
+		for i := r[0]; i < r[1]; i++ {
+			if offset := text.offsets[i]; offset < len(data) {
+				masked[offset] = true
+			}
+		}
+	}
+
+	result := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		if !masked[i] {
+			result = append(result, data[i])
+			i++
+			continue
+		}
+
+		c, size := utf8.DecodeRune(data[i:])
+		if c < 0x20 || c == 0x7f || c == ' ' {
+			result = append(result, data[i:i+size]...)
+		} else {
+			for w := runeWidth(c); w > 0; w-- {
+				result = append(result, Mask)
+			}
+		}
+		i += size
+	}
+
+	return result
+}
+
+// skipRunes returns the position after n characters from start, up to end.
+func skipRunes(data []byte, start int, end int, n int) int {
+	for ; n > 0 && start < end; n-- {
+		_, size := utf8.DecodeRune(data[start:end])
+		start += size
+	}
+	return start
+}
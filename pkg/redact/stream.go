@@ -0,0 +1,140 @@
+package redact
+
+import (
+	"unicode/utf8"
+)
+
+// DefaultWindow is the default maximum number of bytes held back by Stream.
+const DefaultWindow = 4096
+
+// Stream redacts output written in arbitrary chunks.
+// It holds back the last line of output, or an unfinished secret spanning lines,
+// so that secrets split between chunks are found.
+// Escape sequences are never masked, even when split between chunks.
+// A Stream is not safe for concurrent use.
+type Stream struct {
+	redactor *Redactor
+	window   int
+
+	buffer []byte
+	// state of the parser of escape sequences at the beginning of buffer
+	state int
+}
+
+// NewStream creates a new instance of Stream holding back up to window bytes.
+func NewStream(redactor *Redactor, window int) *Stream {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	return &Stream{
+		redactor: redactor,
+		window:   window,
+		state:    stateGround,
+	}
+}
+
+// What emit returns of the output held back
+const (
+	// complete lines not part of unfinished secrets
+	emitLines = iota
+	// output not part of unfinished secrets
+	emitIdle
+	// all the output
+	emitAll
+)
+
+// Push appends p to the stream and returns the redacted output
+// which is ready to be emitted.
+func (stream *Stream) Push(p []byte) []byte {
+	stream.buffer = append(stream.buffer, p...)
+	return stream.emit(emitLines)
+}
+
+// Idle returns the output held back, redacted, except an unfinished secret
+// spanning lines, which is kept until it is finished or turns out not to be a secret.
+// It is supposed to be called when no more output comes for a while.
+func (stream *Stream) Idle() []byte {
+	return stream.emit(emitIdle)
+}
+
+// Flush returns all the output held back, redacted.
+func (stream *Stream) Flush() []byte {
+	return stream.emit(emitAll)
+}
+
+// Buffered returns the number of bytes held back.
+func (stream *Stream) Buffered() int {
+	return len(stream.buffer)
+}
+
+func (stream *Stream) emit(mode int) []byte {
+	data := stream.buffer
+	if len(data) == 0 {
+		return nil
+	}
+
+	text := scan(data, stream.state)
+	ranges := stream.redactor.find(text.visible)
+
+	cut := len(data)
+	if mode != emitAll {
+		cut = stream.cut(data, text, ranges, mode == emitLines)
+	}
+
+	output := make([]byte, cut)
+	copy(output, data[:cut])
+	if len(ranges) > 0 {
+		output = mask(output, text, ranges)
+	}
+
+	stream.state = text.states[cut]
+	stream.buffer = append(make([]byte, 0, len(data)-cut), data[cut:]...)
+
+	return output
+}
+
+// cut returns the length of data which can be emitted.
+// The last line is held back if lines is true.
+func (stream *Stream) cut(data []byte, text *text, ranges [][2]int, lines bool) int {
+	end := len(data)
+
+	cut := end
+	if lines {
+		cut = 0
+		for i := end - 1; i >= 0; i-- {
+			if (data[i] == '\n' || data[i] == '\r') && text.states[i] == stateGround {
+				cut = i + 1
+				break
+			}
+		}
+	}
+	if i := stream.redactor.hold(text.visible); i >= 0 && text.offsets[i] < cut {
+		cut = text.offsets[i]
+	}
+	for _, r := range ranges {
+		start, stop := text.offsets[r[0]], text.offsets[r[1]-1]+1
+		if start < cut && cut < stop {
+			cut = start
+		}
+	}
+
+	if end-cut <= stream.window {
+		return cut
+	}
+
+	// the window is exceeded, emit as much as possible without splitting
+	// characters, and secrets which have been found
+	cut = end - stream.window
+	for cut < end && !utf8.RuneStart(data[cut]) {
+		cut++
+	}
+	for _, r := range ranges {
+		start, stop := text.offsets[r[0]], text.offsets[r[1]-1]+1
+		if start < cut && cut < stop {
+			cut = stop
+		}
+	}
+
+	return cut
+}
@@ -0,0 +1,100 @@
+// Package token signs and verifies expiring values with HMAC-SHA256
+// so that they can be handed to clients in cookies and URLs.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalid is returned for tokens that are malformed or not signed with the key.
+	ErrInvalid = errors.New("invalid token")
+	// ErrExpired is returned for tokens signed with the key but expired.
+	ErrExpired = errors.New("token expired")
+)
+
+// Signer signs values with a secret key.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a new instance of Signer with key.
+// A random key is generated if key is empty, which makes tokens invalid
+// after the process exits.
+func NewSigner(key []byte) (*Signer, error) {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, errors.Wrapf(err, "failed to generate a signing key")
+		}
+	}
+	return &Signer{key: key}, nil
+}
+
+// Derive returns a Signer with a key derived for purpose,
+// whose tokens are invalid for signers of other purposes.
+func (signer *Signer) Derive(purpose string) *Signer {
+	return &Signer{key: signer.mac(purpose)}
+}
+
+type envelope struct {
+	Expires int64           `json:"exp"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Sign returns a token of v marshaled in JSON, which expires at expires.
+func (signer *Signer) Sign(v interface{}, expires time.Time) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal token data")
+	}
+	payload, err := json.Marshal(envelope{Expires: expires.Unix(), Data: data})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal token")
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signer.mac(encoded)), nil
+}
+
+// Verify unmarshals the data of token into v if token is signed with the key and not expired.
+// It returns the expiry of the token.
+func (signer *Signer) Verify(token string, v interface{}) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return time.Time{}, ErrInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, signer.mac(parts[0])) {
+		return time.Time{}, ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return time.Time{}, ErrInvalid
+	}
+	var env envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return time.Time{}, ErrInvalid
+	}
+	expires := time.Unix(env.Expires, 0)
+	if !time.Now().Before(expires) {
+		return expires, ErrExpired
+	}
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		return expires, ErrInvalid
+	}
+	return expires, nil
+}
+
+func (signer *Signer) mac(data string) []byte {
+	m := hmac.New(sha256.New, signer.key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
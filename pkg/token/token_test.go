@@ -0,0 +1,91 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testData struct {
+	User string `json:"u"`
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer, err := NewSigner([]byte("key"))
+	if err != nil {
+		t.Fatalf("NewSigner() = %v", err)
+	}
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	raw, err := signer.Sign(testData{User: "alice"}, expires)
+	if err != nil {
+		t.Fatalf("Sign() = %v", err)
+	}
+
+	var data testData
+	got, err := signer.Verify(raw, &data)
+	if err != nil {
+		t.Fatalf("Verify() = %v", err)
+	}
+	if data.User != "alice" || !got.Equal(expires) {
+		t.Errorf("Verify() = %+v expiring at %s, want alice expiring at %s", data, got, expires)
+	}
+
+	// tokens survive restarts with the same key
+	other, _ := NewSigner([]byte("key"))
+	if _, err := other.Verify(raw, &data); err != nil {
+		t.Errorf("Verify() with the same key = %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	signer, _ := NewSigner([]byte("key"))
+	raw, _ := signer.Sign(testData{User: "alice"}, time.Now().Add(time.Hour))
+	expired, _ := signer.Sign(testData{User: "alice"}, time.Now().Add(-time.Second))
+	otherKey, _ := NewSigner([]byte("other"))
+	otherRaw, _ := otherKey.Sign(testData{User: "mallory"}, time.Now().Add(time.Hour))
+	derived, _ := signer.Derive("cookie").Sign(testData{User: "alice"}, time.Now().Add(time.Hour))
+	payload, sig, _ := strings.Cut(raw, ".")
+	otherPayload, _, _ := strings.Cut(otherRaw, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{name: "expired", token: expired, want: ErrExpired},
+		{name: "signed with other key", token: otherRaw, want: ErrInvalid},
+		{name: "signed for other purpose", token: derived, want: ErrInvalid},
+		{name: "payload replaced", token: otherPayload + "." + sig, want: ErrInvalid},
+		{name: "no signature", token: payload, want: ErrInvalid},
+		{name: "empty signature", token: payload + ".", want: ErrInvalid},
+		{name: "extra part", token: raw + ".x", want: ErrInvalid},
+		{name: "malformed signature", token: payload + ".!!", want: ErrInvalid},
+		{name: "empty", token: "", want: ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data testData
+			if _, err := signer.Verify(tt.token, &data); err != tt.want {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+			if data.User != "" {
+				t.Errorf("data of a rejected token is unmarshaled: %+v", data)
+			}
+		})
+	}
+}
+
+func TestNewSignerRandomKey(t *testing.T) {
+	a, err := NewSigner(nil)
+	if err != nil {
+		t.Fatalf("NewSigner() = %v", err)
+	}
+	b, _ := NewSigner(nil)
+	raw, _ := a.Sign(testData{User: "alice"}, time.Now().Add(time.Hour))
+
+	var data testData
+	if _, err := b.Verify(raw, &data); err != ErrInvalid {
+		t.Errorf("Verify() with another random key = %v, want %v", err, ErrInvalid)
+	}
+}
@@ -0,0 +1,266 @@
+// Package users authenticates users listed in an htpasswd-style file
+// and tells their roles.
+//
+// Each line of the file is formatted as below. Empty lines and lines starting
+// with # are ignored.
+//
+//	name:hash[:role]
+//
+// hash is a bcrypt hash ($2a$, $2b$ or $2y$) or an argon2 hash in the PHC string
+// format ($argon2id$ or $argon2i$). A hash of * disables password
+// authentication of the user, who can still be given a role when authenticated
+// otherwise. role is viewer if omitted.
+package users
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Role is what users are allowed to do.
+type Role string
+
+const (
+	// RoleViewer only watches terminals
+	RoleViewer Role = "viewer"
+	// RoleOperator writes input to terminals
+	RoleOperator Role = "operator"
+)
+
+// CanWrite returns true if users of the role can write input to terminals.
+func (role Role) CanWrite() bool {
+	return role == RoleOperator
+}
+
+// User is an entry of the users file.
+type User struct {
+	Name string
+	Role Role
+	hash string
+	// argon2 is the parsed hash if hash is an argon2 hash
+	argon2 *argon2Hash
+}
+
+// File is a users file, reloaded when modified.
+type File struct {
+	path string
+
+	mutex   sync.Mutex
+	users   map[string]*User
+	modTime time.Time
+}
+
+// dummyHash is compared for unknown users to take as long as known ones.
+var dummyHash struct {
+	once sync.Once
+	hash []byte
+}
+
+// Load reads the users file at path.
+func Load(path string) (*File, error) {
+	f := &File{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Authenticate returns the user named name if password matches the hash.
+func (f *File) Authenticate(name string, password string) (*User, bool) {
+	user, ok := f.Lookup(name)
+	if !ok {
+		dummyHash.once.Do(func() {
+			dummyHash.hash, _ = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+		})
+		bcrypt.CompareHashAndPassword(dummyHash.hash, []byte(password))
+		return nil, false
+	}
+	if !user.verifyPassword(password) {
+		return nil, false
+	}
+	return user, true
+}
+
+// Lookup returns the user named name.
+func (f *File) Lookup(name string) (*User, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if info, err := os.Stat(f.path); err == nil && !info.ModTime().Equal(f.modTime) {
+		// the previous users are kept if the file is broken
+		f.reloadLocked()
+	}
+	user, ok := f.users[name]
+	return user, ok
+}
+
+func (f *File) reload() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.reloadLocked()
+}
+
+func (f *File) reloadLocked() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open users file")
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Wrapf(err, "failed to stat users file")
+	}
+
+	users := map[string]*User{}
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		user, err := parseLine(line)
+		if err != nil {
+			return errors.Wrapf(err, "invalid users file `%s` at line %d", f.path, lineNum)
+		}
+		users[user.Name] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrapf(err, "failed to read users file")
+	}
+
+	f.users = users
+	f.modTime = info.ModTime()
+	return nil
+}
+
+func parseLine(line string) (*User, error) {
+	fields := strings.Split(line, ":")
+	if len(fields) < 2 || len(fields) > 3 || fields[0] == "" {
+		return nil, errors.New("expected name:hash[:role]")
+	}
+	user := &User{Name: fields[0], hash: fields[1], Role: RoleViewer}
+	if len(fields) == 3 && fields[2] != "" {
+		user.Role = Role(fields[2])
+	}
+	switch user.Role {
+	case RoleViewer, RoleOperator:
+	default:
+		return nil, errors.Errorf("unknown role `%s`", user.Role)
+	}
+	switch {
+	case user.hash == "*":
+	case isBcrypt(user.hash):
+		if _, err := bcrypt.Cost([]byte(user.hash)); err != nil {
+			return nil, errors.Wrapf(err, "invalid bcrypt hash")
+		}
+	case strings.HasPrefix(user.hash, "$argon2"):
+		hash, err := parseArgon2(user.hash)
+		if err != nil {
+			return nil, err
+		}
+		user.argon2 = hash
+	default:
+		return nil, errors.New("unsupported password hash")
+	}
+	return user, nil
+}
+
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (user *User) verifyPassword(password string) bool {
+	switch {
+	case user.argon2 != nil:
+		return user.argon2.verify(password)
+	case isBcrypt(user.hash):
+		return bcrypt.CompareHashAndPassword([]byte(user.hash), []byte(password)) == nil
+	}
+	return false
+}
+
+// argon2Hash is an argon2 hash with its parameters.
+type argon2Hash struct {
+	variant    string
+	memory     uint32
+	iterations uint32
+	threads    uint8
+	salt       []byte
+	key        []byte
+}
+
+// parseArgon2 parses a hash formatted as
+// $argon2id$v=19$m=65536,t=3,p=4$salt$hash in unpadded base64.
+func parseArgon2(hash string) (*argon2Hash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, errors.New("invalid argon2 hash: expected $variant$v=version$m=memory,t=iterations,p=threads$salt$hash")
+	}
+	h := &argon2Hash{variant: parts[1]}
+	if h.variant != "argon2id" && h.variant != "argon2i" {
+		return nil, errors.Errorf("invalid argon2 hash: unsupported variant `%s`", h.variant)
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.Errorf("invalid argon2 hash: unsupported version `%s`", parts[2])
+	}
+	params := strings.Split(parts[3], ",")
+	if len(params) != 3 {
+		return nil, errors.Errorf("invalid argon2 hash: unexpected parameters `%s`", parts[3])
+	}
+	// the parameters must be positive for argon2 not to panic
+	memory, err := parseArgon2Param(params[0], "m", math.MaxUint32)
+	if err != nil {
+		return nil, err
+	}
+	iterations, err := parseArgon2Param(params[1], "t", math.MaxUint32)
+	if err != nil {
+		return nil, err
+	}
+	threads, err := parseArgon2Param(params[2], "p", math.MaxUint8)
+	if err != nil {
+		return nil, err
+	}
+	h.memory, h.iterations, h.threads = memory, iterations, uint8(threads)
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errors.Wrapf(err, "invalid argon2 hash: malformed salt")
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return nil, errors.New("invalid argon2 hash: malformed hash")
+	}
+	return h, nil
+}
+
+// parseArgon2Param parses the parameter formatted as name=value, whose value must be in [1, max].
+func parseArgon2Param(param string, name string, max uint64) (uint32, error) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(param, name+"="), 10, 32)
+	if !strings.HasPrefix(param, name+"=") || err != nil || value < 1 || value > max {
+		return 0, errors.Errorf("invalid argon2 hash: parameter `%s` is not a positive number up to %d", param, max)
+	}
+	return uint32(value), nil
+}
+
+func (h *argon2Hash) verify(password string) bool {
+	var key []byte
+	switch h.variant {
+	case "argon2id":
+		key = argon2.IDKey([]byte(password), h.salt, h.iterations, h.memory, h.threads, uint32(len(h.key)))
+	case "argon2i":
+		key = argon2.Key([]byte(password), h.salt, h.iterations, h.memory, h.threads, uint32(len(h.key)))
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
@@ -0,0 +1,175 @@
+package users
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func bcryptHash(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword() = %v", err)
+	}
+	return string(hash)
+}
+
+// formatArgon2 returns a hash of password computed with m=64,t=1,p=1,
+// whose parameters are written as params.
+func formatArgon2(variant string, password string, params string) string {
+	salt := []byte("saltsalt")
+	var key []byte
+	if variant == "argon2id" {
+		key = argon2.IDKey([]byte(password), salt, 1, 64, 1, 32)
+	} else {
+		key = argon2.Key([]byte(password), salt, 1, 64, 1, 32)
+	}
+	return fmt.Sprintf("$%s$v=%d$%s$%s$%s", variant, argon2.Version, params,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func writeUsersFile(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatalf("WriteFile() = %v", err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	writeUsersFile(t, path,
+		"# users",
+		"",
+		"alice:"+bcryptHash(t, "alice-password")+":operator",
+		"bob:"+formatArgon2("argon2id", "bob-password", "m=64,t=1,p=1"),
+		"carol:"+formatArgon2("argon2i", "carol-password", "m=64,t=1,p=1")+":viewer",
+		"dave:*:operator",
+	)
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantOK   bool
+		wantRole Role
+	}{
+		{name: "alice", password: "alice-password", wantOK: true, wantRole: RoleOperator},
+		{name: "alice", password: "bob-password"},
+		{name: "bob", password: "bob-password", wantOK: true, wantRole: RoleViewer},
+		{name: "bob", password: "bob-passwore"},
+		{name: "carol", password: "carol-password", wantOK: true, wantRole: RoleViewer},
+		{name: "carol", password: ""},
+		{name: "dave", password: "*"},
+		{name: "dave", password: ""},
+		{name: "mallory", password: "alice-password"},
+	}
+
+	for _, tt := range tests {
+		user, ok := file.Authenticate(tt.name, tt.password)
+		if ok != tt.wantOK {
+			t.Errorf("Authenticate(%q, %q) = %t, want %t", tt.name, tt.password, ok, tt.wantOK)
+			continue
+		}
+		if ok && (user.Name != tt.name || user.Role != tt.wantRole) {
+			t.Errorf("Authenticate(%q) = %s as %s, want %s", tt.name, user.Name, user.Role, tt.wantRole)
+		}
+	}
+
+	// users without passwords can be given roles when authenticated otherwise
+	if user, ok := file.Lookup("dave"); !ok || !user.Role.CanWrite() {
+		t.Errorf("Lookup(dave) = %v, %t; want an operator", user, ok)
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	argon2id := formatArgon2("argon2id", "password", "m=64,t=1,p=1")
+	bcrypted := bcryptHash(t, "password")
+
+	tests := []struct {
+		name    string
+		line    string
+		wantErr bool
+	}{
+		{name: "bcrypt", line: "alice:" + bcrypted},
+		{name: "bcrypt 2y", line: "alice:$2y$" + strings.TrimPrefix(bcrypted, "$2a$")},
+		{name: "argon2id", line: "alice:" + argon2id + ":operator"},
+		{name: "argon2i", line: "alice:" + formatArgon2("argon2i", "password", "m=64,t=1,p=1")},
+		{name: "no password", line: "alice:*"},
+		{name: "empty role", line: "alice:*:"},
+		{name: "no hash", line: "alice", wantErr: true},
+		{name: "no name", line: ":*", wantErr: true},
+		{name: "too many fields", line: "alice:*:operator:x", wantErr: true},
+		{name: "unknown role", line: "alice:*:admin", wantErr: true},
+		{name: "plain password", line: "alice:password", wantErr: true},
+		{name: "truncated bcrypt", line: "alice:" + bcrypted[:20], wantErr: true},
+		{name: "argon2d", line: "alice:" + strings.Replace(argon2id, "argon2id", "argon2d", 1), wantErr: true},
+		{name: "argon2 other version", line: "alice:" + strings.Replace(argon2id, "v=19", "v=16", 1), wantErr: true},
+		{name: "argon2 no threads", line: "alice:" + strings.Replace(argon2id, "p=1", "p=0", 1), wantErr: true},
+		{name: "argon2 no iterations", line: "alice:" + strings.Replace(argon2id, "t=1", "t=0", 1), wantErr: true},
+		{name: "argon2 no memory", line: "alice:" + strings.Replace(argon2id, "m=64", "m=0", 1), wantErr: true},
+		{name: "argon2 too many threads", line: "alice:" + strings.Replace(argon2id, "p=1", "p=256", 1), wantErr: true},
+		{name: "argon2 negative parameter", line: "alice:" + strings.Replace(argon2id, "t=1", "t=-1", 1), wantErr: true},
+		{name: "argon2 parameters out of order", line: "alice:" + strings.Replace(argon2id, "m=64,t=1", "t=1,m=64", 1), wantErr: true},
+		{name: "argon2 extra parameter", line: "alice:" + strings.Replace(argon2id, "p=1", "p=1,k=2", 1), wantErr: true},
+		{name: "argon2 malformed salt", line: "alice:" + strings.Replace(argon2id, "c2FsdHNhbHQ", "!!", 1), wantErr: true},
+		{name: "argon2 no hash", line: "alice:" + argon2id[:strings.LastIndex(argon2id, "$")+1], wantErr: true},
+		{name: "argon2 missing part", line: "alice:$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseLine(%q) = %v, want error %t", tt.line, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	writeUsersFile(t, path, "alice:*:operator")
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+
+	// the previous users are kept while the file is broken
+	writeUsersFile(t, path, "bob:"+strings.Replace(formatArgon2("argon2id", "password", "m=64,t=1,p=1"), "p=1", "p=0", 1))
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	if _, ok := file.Lookup("alice"); !ok {
+		t.Errorf("Lookup(alice) failed after the file is broken")
+	}
+	if _, ok := file.Lookup("bob"); ok {
+		t.Errorf("Lookup(bob) succeeded with a broken hash")
+	}
+
+	writeUsersFile(t, path, "bob:*")
+	os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second))
+	if _, ok := file.Lookup("bob"); !ok {
+		t.Errorf("Lookup(bob) failed after the file is fixed")
+	}
+	if _, ok := file.Lookup("alice"); ok {
+		t.Errorf("Lookup(alice) succeeded after removed from the file")
+	}
+}
+
+func TestLoadRejectsMalformedHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	writeUsersFile(t, path, "alice:*", "bob:$argon2id$v=19$m=64,t=1,p=0$c2FsdA$aGFzaA")
+	_, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Load() = %v, want error at line 2", err)
+	}
+}
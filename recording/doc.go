@@ -0,0 +1,3 @@
+// Package recording provides implementations of webtty.Recorder
+// storing session recordings in files, HTTP endpoints and streams.
+package recording
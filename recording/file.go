@@ -0,0 +1,56 @@
+package recording
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/pkg/homedir"
+	"github.com/labbs/webtty/webtty"
+)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// FileRecorder saves each session into an asciicast v2 file in a directory.
+type FileRecorder struct {
+	dir string
+}
+
+// NewFileRecorder creates a new instance of FileRecorder.
+// dir is created when it doesn't exist.
+func NewFileRecorder(dir string) (*FileRecorder, error) {
+	dir = homedir.Expand(dir)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create recording directory `%s`", dir)
+	}
+
+	return &FileRecorder{dir: dir}, nil
+}
+
+// OpenSession creates a file named with the start time,
+// the session ID and the remote address of the session.
+func (recorder *FileRecorder) OpenSession(info webtty.RecordingInfo) (webtty.RecordingSession, error) {
+	name := fmt.Sprintf(
+		"%s_%s_%s.cast",
+		info.StartTime.UTC().Format("20060102T150405Z"),
+		info.SessionID,
+		strings.Trim(unsafeFileNameChars.ReplaceAllString(info.RemoteAddr, "-"), "-"),
+	)
+	file, err := os.OpenFile(filepath.Join(recorder.dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create recording file")
+	}
+
+	session, err := webtty.NewAsciicastRecorder(file, info)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return session, nil
+}
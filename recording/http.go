@@ -0,0 +1,236 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/webtty"
+)
+
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 5 * time.Second
+	DefaultMaxRetries    = 3
+	DefaultRetryInterval = 1 * time.Second
+	DefaultTimeout       = 10 * time.Second
+
+	// number of events buffered for each session before dropping them
+	httpQueueSize = 4096
+)
+
+var (
+	errSessionClosed = errors.New("recording session closed")
+	errQueueFull     = errors.New("recording queue is full, event dropped")
+)
+
+// HTTPOptions configures an HTTPRecorder.
+type HTTPOptions struct {
+	// Buffered events are sent when this number of events are buffered
+	BatchSize int
+	// Buffered events are sent at least at this interval
+	FlushInterval time.Duration
+	// Number of retries of a failed request
+	MaxRetries int
+	// Wait before the first retry, doubled for each retry
+	RetryInterval time.Duration
+	// Timeout of each request with the default client
+	Timeout time.Duration
+	// Client to send requests, a client with Timeout is used when nil
+	Client *http.Client
+}
+
+// HTTPRecorder sends events of sessions to an HTTP(S) endpoint
+// in batches of JSON documents using POST requests.
+type HTTPRecorder struct {
+	url     string
+	options HTTPOptions
+}
+
+type httpBatch struct {
+	Session webtty.RecordingInfo `json:"session"`
+	Events  []httpEvent          `json:"events"`
+	// true for the last batch of the session
+	Final bool `json:"final"`
+}
+
+type httpEvent struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Data string    `json:"data"`
+}
+
+// NewHTTPRecorder creates a new instance of HTTPRecorder sending requests to url.
+// Zero values in options except MaxRetries are replaced with the defaults.
+func NewHTTPRecorder(url string, options HTTPOptions) *HTTPRecorder {
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultBatchSize
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = DefaultFlushInterval
+	}
+	if options.MaxRetries < 0 {
+		options.MaxRetries = 0
+	}
+	if options.RetryInterval <= 0 {
+		options.RetryInterval = DefaultRetryInterval
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.Client == nil {
+		// endpoints not responding must not block closing sessions forever
+		options.Client = &http.Client{Timeout: options.Timeout}
+	}
+
+	return &HTTPRecorder{
+		url:     url,
+		options: options,
+	}
+}
+
+// OpenSession starts sending the events of the session in background.
+func (recorder *HTTPRecorder) OpenSession(info webtty.RecordingInfo) (webtty.RecordingSession, error) {
+	session := &httpSession{
+		recorder: recorder,
+		info:     info,
+		events:   make(chan webtty.RecordingEvent, httpQueueSize),
+		done:     make(chan struct{}),
+	}
+	go session.run()
+
+	return session, nil
+}
+
+func (recorder *HTTPRecorder) post(body []byte) error {
+	req, err := http.NewRequest("POST", recorder.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := recorder.options.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected response status `%s`", resp.Status)
+	}
+
+	return nil
+}
+
+type httpSession struct {
+	recorder *HTTPRecorder
+	info     webtty.RecordingInfo
+
+	events chan webtty.RecordingEvent
+	done   chan struct{}
+	// the last error occurred sending events
+	err    error
+	closed bool
+	mutex  sync.Mutex
+}
+
+// WriteEvent queues the event to be sent.
+// It never blocks, the event is dropped when the queue is full.
+func (session *httpSession) WriteEvent(event webtty.RecordingEvent) error {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if session.closed {
+		return errSessionClosed
+	}
+
+	select {
+	case session.events <- event:
+		return nil
+	default:
+		return errQueueFull
+	}
+}
+
+// Close sends the remaining events and waits for the completion.
+// It returns the last error occurred sending events of the session.
+func (session *httpSession) Close() error {
+	session.mutex.Lock()
+	if session.closed {
+		session.mutex.Unlock()
+		return errSessionClosed
+	}
+	session.closed = true
+	close(session.events)
+	session.mutex.Unlock()
+
+	<-session.done
+
+	return session.err
+}
+
+func (session *httpSession) run() {
+	defer close(session.done)
+
+	ticker := time.NewTicker(session.recorder.options.FlushInterval)
+	defer ticker.Stop()
+
+	batch := []httpEvent{}
+	for {
+		select {
+		case event, ok := <-session.events:
+			if !ok {
+				session.flush(batch, true)
+				return
+			}
+			batch = append(batch, httpEvent{
+				Time: event.Time,
+				Type: event.Type,
+				Data: event.Data,
+			})
+			if len(batch) >= session.recorder.options.BatchSize {
+				session.flush(batch, false)
+				batch = []httpEvent{}
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				session.flush(batch, false)
+				batch = []httpEvent{}
+			}
+		}
+	}
+}
+
+// flush sends the events, retrying on failures.
+func (session *httpSession) flush(events []httpEvent, final bool) {
+	body, err := json.Marshal(httpBatch{
+		Session: session.info,
+		Events:  events,
+		Final:   final,
+	})
+	if err != nil {
+		session.err = errors.Wrapf(err, "failed to marshal recording events")
+		return
+	}
+
+	options := session.recorder.options
+	for attempt := 0; attempt <= options.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(options.RetryInterval << (attempt - 1))
+		}
+		err = session.recorder.post(body)
+		if err == nil {
+			return
+		}
+	}
+
+	session.err = errors.Wrapf(err, "failed to send %d events of session %s", len(events), session.info.SessionID)
+	log.Printf("Recording: %s", session.err)
+}
@@ -0,0 +1,213 @@
+package recording
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labbs/webtty/webtty"
+)
+
+// testEndpoint records the batches posted to it,
+// failing the first failures requests with 503.
+type testEndpoint struct {
+	failures int
+
+	mutex    sync.Mutex
+	requests int
+	batches  []httpBatch
+}
+
+func (te *testEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+
+	te.requests++
+	if te.requests <= te.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var batch httpBatch
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	te.batches = append(te.batches, batch)
+}
+
+func (te *testEndpoint) received() (int, []httpBatch) {
+	te.mutex.Lock()
+	defer te.mutex.Unlock()
+	return te.requests, append([]httpBatch{}, te.batches...)
+}
+
+func openTestSession(t *testing.T, te *testEndpoint, options HTTPOptions) webtty.RecordingSession {
+	t.Helper()
+	server := httptest.NewServer(te)
+	t.Cleanup(server.Close)
+
+	session, err := NewHTTPRecorder(server.URL, options).OpenSession(webtty.RecordingInfo{SessionID: "test"})
+	if err != nil {
+		t.Fatalf("OpenSession() = %v", err)
+	}
+	return session
+}
+
+func writeTestEvents(t *testing.T, session webtty.RecordingSession, data ...string) {
+	t.Helper()
+	for _, d := range data {
+		if err := session.WriteEvent(webtty.RecordingEvent{Time: time.Now(), Type: webtty.EventOutput, Data: d}); err != nil {
+			t.Fatalf("WriteEvent() = %v", err)
+		}
+	}
+}
+
+func TestHTTPRecorderBatches(t *testing.T) {
+	te := &testEndpoint{}
+	session := openTestSession(t, te, HTTPOptions{BatchSize: 2, FlushInterval: time.Hour})
+
+	writeTestEvents(t, session, "a", "b", "c")
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	_, batches := te.received()
+	if len(batches) != 2 {
+		t.Fatalf("received %d batches, want 2", len(batches))
+	}
+	if len(batches[0].Events) != 2 || batches[0].Final {
+		t.Errorf("first batch has %d events, final %t; want 2 events, not final", len(batches[0].Events), batches[0].Final)
+	}
+	if len(batches[1].Events) != 1 || batches[1].Events[0].Data != "c" || !batches[1].Final {
+		t.Errorf("last batch = %+v, want event c, final", batches[1])
+	}
+	if batches[0].Session.SessionID != "test" {
+		t.Errorf("session ID = %q, want test", batches[0].Session.SessionID)
+	}
+}
+
+func TestHTTPRecorderFlushesOnClose(t *testing.T) {
+	te := &testEndpoint{}
+	session := openTestSession(t, te, HTTPOptions{BatchSize: 100, FlushInterval: time.Hour})
+
+	writeTestEvents(t, session, "a", "b")
+	if requests, _ := te.received(); requests != 0 {
+		t.Fatalf("%d requests sent before close, want 0", requests)
+	}
+	if err := session.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	_, batches := te.received()
+	if len(batches) != 1 || len(batches[0].Events) != 2 || !batches[0].Final {
+		t.Fatalf("received %+v, want one final batch of 2 events", batches)
+	}
+	if err := session.WriteEvent(webtty.RecordingEvent{Type: webtty.EventOutput}); err != errSessionClosed {
+		t.Errorf("WriteEvent() after close = %v, want %v", err, errSessionClosed)
+	}
+}
+
+func TestHTTPRecorderFlushesAtInterval(t *testing.T) {
+	te := &testEndpoint{}
+	session := openTestSession(t, te, HTTPOptions{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer session.Close()
+
+	writeTestEvents(t, session, "a")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, batches := te.received(); len(batches) == 1 {
+			if batches[0].Final {
+				t.Errorf("batch sent at interval is final")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no batch sent at interval")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestHTTPRecorderRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		maxRetries int
+		wantErr    bool
+	}{
+		{name: "succeeds after retries", failures: 2, maxRetries: 2, wantErr: false},
+		{name: "gives up", failures: 3, maxRetries: 2, wantErr: true},
+		{name: "no retries", failures: 1, maxRetries: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := &testEndpoint{failures: tt.failures}
+			session := openTestSession(t, te, HTTPOptions{
+				BatchSize:     100,
+				FlushInterval: time.Hour,
+				MaxRetries:    tt.maxRetries,
+				RetryInterval: time.Millisecond,
+			})
+
+			writeTestEvents(t, session, "a")
+			err := session.Close()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Close() = %v, want error %t", err, tt.wantErr)
+			}
+
+			requests, batches := te.received()
+			if requests != tt.maxRetries+1 {
+				t.Errorf("%d requests sent, want %d", requests, tt.maxRetries+1)
+			}
+			if !tt.wantErr && len(batches) != 1 {
+				t.Errorf("received %d batches, want 1", len(batches))
+			}
+		})
+	}
+}
+
+func TestHTTPRecorderTimesOut(t *testing.T) {
+	// the endpoint never responds until the client gives up
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	recorder := NewHTTPRecorder(server.URL, HTTPOptions{FlushInterval: time.Hour, Timeout: 50 * time.Millisecond})
+	session, err := recorder.OpenSession(webtty.RecordingInfo{SessionID: "test"})
+	if err != nil {
+		t.Fatalf("OpenSession() = %v", err)
+	}
+	writeTestEvents(t, session, "a")
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- session.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err == nil {
+			t.Errorf("Close() = nil, want timeout error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Close() blocked by endpoint not responding")
+	}
+}
+
+func TestHTTPRecorderDefaultTimeout(t *testing.T) {
+	recorder := NewHTTPRecorder("http://example.com", HTTPOptions{})
+	if timeout := recorder.options.Client.Timeout; timeout != DefaultTimeout {
+		t.Errorf("client timeout = %s, want %s", timeout, DefaultTimeout)
+	}
+}
@@ -0,0 +1,94 @@
+package recording
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/webtty"
+)
+
+// JSONLinesRecorder writes events of all sessions to a stream,
+// typically the standard output, as JSON objects separated by new lines.
+type JSONLinesRecorder struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+type jsonLine struct {
+	SessionID string                `json:"session_id"`
+	Time      time.Time             `json:"time"`
+	Type      string                `json:"type"`
+	Data      string                `json:"data,omitempty"`
+	Info      *webtty.RecordingInfo `json:"info,omitempty"`
+}
+
+const (
+	lineTypeOpen  = "open"
+	lineTypeClose = "close"
+)
+
+// NewJSONLinesRecorder creates a new instance of JSONLinesRecorder.
+func NewJSONLinesRecorder(writer io.Writer) *JSONLinesRecorder {
+	return &JSONLinesRecorder{writer: writer}
+}
+
+// OpenSession writes a line describing the session.
+func (recorder *JSONLinesRecorder) OpenSession(info webtty.RecordingInfo) (webtty.RecordingSession, error) {
+	err := recorder.writeLine(jsonLine{
+		SessionID: info.SessionID,
+		Time:      info.StartTime,
+		Type:      lineTypeOpen,
+		Info:      &info,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonLinesSession{
+		recorder:  recorder,
+		sessionID: info.SessionID,
+	}, nil
+}
+
+func (recorder *JSONLinesRecorder) writeLine(line jsonLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal recording line")
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	_, err = recorder.writer.Write(append(data, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "failed to write recording line")
+	}
+
+	return nil
+}
+
+type jsonLinesSession struct {
+	recorder  *JSONLinesRecorder
+	sessionID string
+}
+
+func (session *jsonLinesSession) WriteEvent(event webtty.RecordingEvent) error {
+	return session.recorder.writeLine(jsonLine{
+		SessionID: session.sessionID,
+		Time:      event.Time,
+		Type:      event.Type,
+		Data:      event.Data,
+	})
+}
+
+func (session *jsonLinesSession) Close() error {
+	return session.recorder.writeLine(jsonLine{
+		SessionID: session.sessionID,
+		Time:      time.Now(),
+		Type:      lineTypeClose,
+	})
+}
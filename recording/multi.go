@@ -0,0 +1,48 @@
+package recording
+
+import (
+	"github.com/labbs/webtty/webtty"
+)
+
+// MultiRecorder duplicates sessions to all of its recorders.
+type MultiRecorder []webtty.Recorder
+
+func (recorders MultiRecorder) OpenSession(info webtty.RecordingInfo) (webtty.RecordingSession, error) {
+	sessions := make(multiSession, 0, len(recorders))
+	for _, recorder := range recorders {
+		session, err := recorder.OpenSession(info)
+		if err != nil {
+			sessions.Close()
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+type multiSession []webtty.RecordingSession
+
+// WriteEvent writes the event to all the sessions and returns the first error.
+func (sessions multiSession) WriteEvent(event webtty.RecordingEvent) error {
+	var firstErr error
+	for _, session := range sessions {
+		err := session.WriteEvent(event)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close closes all the sessions and returns the first error.
+func (sessions multiSession) Close() error {
+	var firstErr error
+	for _, session := range sessions {
+		err := session.Close()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
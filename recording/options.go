@@ -0,0 +1,87 @@
+package recording
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labbs/webtty/pkg/redact"
+	"github.com/labbs/webtty/webtty"
+)
+
+// Options configures the recorders built by New.
+type Options struct {
+	Enabled bool
+	// Directory to save asciicast files in
+	Dir string
+	// URL of the HTTP endpoint to send events to
+	URL string
+	// Write events to the standard output as JSON lines
+	Stdout bool
+
+	BatchSize     int
+	FlushInterval int // in seconds
+	MaxRetries    int
+
+	// Redactor masks secrets in recorded output and input if not nil
+	Redactor *redact.Redactor
+}
+
+// New builds a recorder writing to all the sinks configured in options.
+// It returns nil when recording is disabled or no sink is configured.
+func New(options *Options) (webtty.Recorder, error) {
+	if !options.Enabled {
+		return nil, nil
+	}
+
+	recorder, err := newSinks(options)
+	if recorder == nil || err != nil {
+		return nil, err
+	}
+	if options.Redactor != nil {
+		recorder = NewRedactedRecorder(recorder, options.Redactor)
+	}
+
+	return recorder, nil
+}
+
+func newSinks(options *Options) (webtty.Recorder, error) {
+	recorders := MultiRecorder{}
+	if options.Dir != "" {
+		file, err := NewFileRecorder(options.Dir)
+		if err != nil {
+			return nil, err
+		}
+		recorders = append(recorders, file)
+	}
+	if options.URL != "" {
+		recorders = append(recorders, NewHTTPRecorder(endpointURL(options.URL), HTTPOptions{
+			BatchSize:     options.BatchSize,
+			FlushInterval: time.Duration(options.FlushInterval) * time.Second,
+			MaxRetries:    options.MaxRetries,
+		}))
+	}
+	if options.Stdout {
+		recorders = append(recorders, NewJSONLinesRecorder(os.Stdout))
+	}
+
+	switch len(recorders) {
+	case 0:
+		return nil, nil
+	case 1:
+		return recorders[0], nil
+	default:
+		return recorders, nil
+	}
+}
+
+// endpointURL supports bare host names for compatibility,
+// which are expanded to `https://{host}/recording/{hostname}`.
+func endpointURL(url string) string {
+	if strings.Contains(url, "://") {
+		return url
+	}
+
+	hostname, _ := os.Hostname()
+	return "https://" + url + "/recording/" + hostname
+}
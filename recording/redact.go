@@ -0,0 +1,101 @@
+package recording
+
+import (
+	"sync"
+	"time"
+
+	"github.com/labbs/webtty/pkg/redact"
+	"github.com/labbs/webtty/webtty"
+)
+
+// RedactedRecorder is a Recorder masking secrets in output and input
+// of sessions before handing events to another recorder.
+type RedactedRecorder struct {
+	recorder webtty.Recorder
+	redactor *redact.Redactor
+}
+
+// NewRedactedRecorder creates a new instance of RedactedRecorder.
+func NewRedactedRecorder(recorder webtty.Recorder, redactor *redact.Redactor) *RedactedRecorder {
+	return &RedactedRecorder{
+		recorder: recorder,
+		redactor: redactor,
+	}
+}
+
+func (rec *RedactedRecorder) OpenSession(info webtty.RecordingInfo) (webtty.RecordingSession, error) {
+	session, err := rec.recorder.OpenSession(info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redactedSession{
+		session: session,
+		streams: map[string]*redact.Stream{
+			webtty.EventOutput: redact.NewStream(rec.redactor, redact.DefaultWindow),
+			webtty.EventInput:  redact.NewStream(rec.redactor, redact.DefaultWindow),
+		},
+	}, nil
+}
+
+type redactedSession struct {
+	session webtty.RecordingSession
+	// streams of the event types containing data to redact
+	streams map[string]*redact.Stream
+	mutex   sync.Mutex
+}
+
+func (rs *redactedSession) WriteEvent(event webtty.RecordingEvent) error {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	// output held back was on the screen when the user typed
+	if event.Type != webtty.EventOutput {
+		if err := rs.flush(webtty.EventOutput, event.Time); err != nil {
+			return err
+		}
+	}
+
+	stream, ok := rs.streams[event.Type]
+	if !ok {
+		return rs.session.WriteEvent(event)
+	}
+	data := stream.Push([]byte(event.Data))
+	if len(data) == 0 {
+		return nil
+	}
+	event.Data = string(data)
+
+	return rs.session.WriteEvent(event)
+}
+
+func (rs *redactedSession) Close() error {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	now := time.Now()
+	var lastErr error
+	for typ := range rs.streams {
+		if err := rs.flush(typ, now); err != nil {
+			lastErr = err
+		}
+	}
+	if err := rs.session.Close(); err != nil {
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+func (rs *redactedSession) flush(typ string, t time.Time) error {
+	data := rs.streams[typ].Flush()
+	if len(data) == 0 {
+		return nil
+	}
+
+	return rs.session.WriteEvent(webtty.RecordingEvent{
+		Time: t,
+		Type: typ,
+		Data: string(data),
+	})
+}
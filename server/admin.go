@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/labbs/webtty/audit"
+)
+
+// handleAdminSessions serves the API for administrators to manage sessions.
+//
+//	GET    {path}api/sessions       lists the active sessions
+//	GET    {path}api/sessions/{id}  describes the session
+//	DELETE {path}api/sessions/{id}  terminates the session
+//	POST   {path}api/sessions/{id}/links  mints a share link of the session
+//
+// Share links point to the terminal page at pagePath.
+func (server *Server) handleAdminSessions(prefix string, pagePath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+		if id == "" {
+			if r.Method != http.MethodGet {
+				writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+				return
+			}
+			sessions := server.sessions.list()
+			infos := make([]sessionInfo, 0, len(sessions))
+			for _, sess := range sessions {
+				infos = append(infos, sess.info())
+			}
+			writeJSON(w, http.StatusOK, infos)
+			return
+		}
+
+		id, sub, _ := strings.Cut(id, "/")
+		sess, ok := server.sessions.get(id)
+		if !ok {
+			writeAdminError(w, http.StatusNotFound, "session not found")
+			return
+		}
+		if sub == "links" {
+			server.handleMintShareLink(w, r, sess, pagePath)
+			return
+		} else if sub != "" {
+			writeAdminError(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, sess.info())
+		case http.MethodDelete:
+			log.Printf("Session %s terminated by administrator %s", sess.id, r.RemoteAddr)
+			auditEvent(server.options.Auditor, audit.Event{
+				Type:       audit.EventSessionKill,
+				SessionID:  sess.id,
+				User:       requestUser(r),
+				RemoteAddr: r.RemoteAddr,
+			})
+			sess.close()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+// handleAdminDashboard serves the page listing sessions for administrators,
+// who can open sessions in the shadow mode from the page.
+func (server *Server) handleAdminDashboard(adminPath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != adminPath {
+			http.NotFound(w, r)
+			return
+		}
+
+		tmpl, err := template.New("admin").Parse(adminTemplate)
+		if err != nil {
+			http.Error(w, "could not parse the embedded template", http.StatusInternalServerError)
+			return
+		}
+
+		path := publicPath(r, adminPath)
+		tmpl.Execute(w, map[string]interface{}{
+			"title":  "GoTTY",
+			"path":   path,
+			"api":    path + "api/sessions",
+			"shadow": path + "shadow/",
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
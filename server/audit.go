@@ -0,0 +1,28 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/labbs/webtty/audit"
+)
+
+// exitWaiter is implemented by slaves running a process.
+type exitWaiter interface {
+	// WaitExit blocks until the process exits and returns its exit code.
+	WaitExit() int
+}
+
+// auditEvent records the event with the auditor if any.
+func auditEvent(auditor audit.Auditor, event audit.Event) {
+	if auditor == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if err := auditor.Audit(event); err != nil {
+		log.Printf("Failed to audit %s event: %s", event.Type, err)
+	}
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// selfSignedValidity is the validity period of generated certificates
+	selfSignedValidity = 365 * 24 * time.Hour
+	// certificateCheckInterval is the interval to check certificate files for changes
+	certificateCheckInterval = 5 * time.Second
+)
+
+// certificate serves the certificate loaded from a pair of files
+// and reloads it without affecting established connections.
+type certificate struct {
+	crtFile string
+	keyFile string
+
+	mutex   sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// newCertificate loads the certificate from crtFile and keyFile.
+func newCertificate(crtFile, keyFile string) (*certificate, error) {
+	c := &certificate{crtFile: crtFile, keyFile: keyFile}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (c *certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.cert, nil
+}
+
+// reload loads the certificate files again.
+// The current certificate is kept if the files are invalid.
+func (c *certificate) reload() error {
+	modTime, err := c.filesModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.crtFile, c.keyFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load TLS certificate `%s`", c.crtFile)
+	}
+
+	c.mutex.Lock()
+	c.cert = &cert
+	c.modTime = modTime
+	c.mutex.Unlock()
+	return nil
+}
+
+// watch reloads the certificate when the files are modified until done is closed.
+func (c *certificate) watch(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			modTime, err := c.filesModTime()
+			if err != nil {
+				continue
+			}
+			c.mutex.RLock()
+			changed := !modTime.Equal(c.modTime)
+			c.mutex.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := c.reload(); err != nil {
+				log.Printf("Failed to reload TLS certificate: %s", err)
+				// wait for the other file of the pair to be updated
+				continue
+			}
+			log.Printf("Reloaded TLS certificate: %s", c.crtFile)
+		case <-done:
+			return
+		}
+	}
+}
+
+// filesModTime returns the latest modification time of the files.
+func (c *certificate) filesModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{c.crtFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "failed to stat TLS certificate file")
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// generateSelfSignedCertificate writes a self-signed certificate and its key to
+// crtFile and keyFile if neither of them exists. hosts are added to the SANs
+// in addition to the hostname and the loopback addresses.
+// It returns true if the files are generated.
+func generateSelfSignedCertificate(crtFile, keyFile string, hosts []string) (bool, error) {
+	_, crtErr := os.Stat(crtFile)
+	_, keyErr := os.Stat(keyFile)
+	if crtErr == nil && keyErr == nil {
+		return false, nil
+	}
+	if crtErr == nil || keyErr == nil {
+		return false, errors.Errorf("only one of TLS certificate files `%s` and `%s` exists", crtFile, keyFile)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to generate a private key")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to generate a serial number")
+	}
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "localhost"
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: hostname, Organization: []string{"GoTTY self-signed"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	seen := map[string]bool{}
+	for _, host := range append([]string{hostname, "localhost", "127.0.0.1", "::1"}, hosts...) {
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		if ip := net.ParseIP(host); ip != nil {
+			if !ip.IsUnspecified() {
+				template.IPAddresses = append(template.IPAddresses, ip)
+			}
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to create a certificate")
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to marshal the private key")
+	}
+
+	if err := writePEM(keyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return false, err
+	}
+	if err := writePEM(crtFile, "CERTIFICATE", der, 0644); err != nil {
+		os.Remove(keyFile)
+		return false, err
+	}
+
+	log.Printf("Generated a self-signed TLS certificate for %s", strings.Join(append(template.DNSNames, ipStrings(template.IPAddresses)...), ", "))
+	log.Printf("TLS certificate SHA-256 fingerprint: %s", fingerprint(der))
+	return true, nil
+}
+
+func writePEM(file string, typ string, der []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return errors.Wrapf(err, "failed to create directory for `%s`", file)
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create `%s`", file)
+	}
+	if err := pem.Encode(f, &pem.Block{Type: typ, Bytes: der}); err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to write `%s`", file)
+	}
+	return f.Close()
+}
+
+func ipStrings(ips []net.IP) []string {
+	strs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		strs = append(strs, ip.String())
+	}
+	return strs
+}
+
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	hexes := make([]string, len(sum))
+	for i, b := range sum {
+		hexes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(hexes, ":")
+}
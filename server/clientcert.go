@@ -0,0 +1,140 @@
+package server
+
+import (
+	"crypto/x509"
+	"log"
+	"net/http"
+
+	"github.com/labbs/webtty/audit"
+)
+
+// clientIdentity is what is known about the client of a request.
+type clientIdentity struct {
+	// User is the name of the authenticated user, if any
+	User string
+	// Email and Groups are given by the OpenID Connect issuer, if any
+	Email  string
+	Groups []string
+	// Role is the role of the user in the users file, if any
+	Role string
+	// Certificate is the verified TLS client certificate, if any
+	Certificate *x509.Certificate
+	// Origin is the host the client loaded the page from
+	Origin string
+	// Share is the share link the client is authorized with, if any
+	Share *shareLink
+}
+
+// requestIdentity returns the identity of the client of r.
+// Users logged in with OpenID Connect are named after their claims, otherwise
+// the verified TLS client certificate takes precedence over basic auth to name the user.
+// Basic auth only names the user once wrapBasicAuth has verified the credential,
+// as the header is sent by clients as they like.
+func requestIdentity(r *http.Request) clientIdentity {
+	id := clientIdentity{Origin: requestOrigin(r)}
+	if user, ok := r.Context().Value(basicAuthUserKey{}).(string); ok {
+		id.User = user
+	}
+	if cert := verifiedClientCert(r); cert != nil {
+		id.Certificate = cert
+		if name := certificateName(cert); name != "" {
+			id.User = name
+		}
+	}
+	if user, ok := r.Context().Value(oidcUserKey{}).(oidcUser); ok {
+		id.User = user.User
+		id.Email = user.Email
+		id.Groups = user.Groups
+	}
+	return id
+}
+
+// verifiedClientCert returns the leaf of the first verified chain of the TLS client, if any.
+func verifiedClientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// certificateName returns the name of the holder of cert,
+// which is the common name or the first email or DNS SAN without it.
+func certificateName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return ""
+}
+
+// titleVariables returns the variables of the identity for the window title.
+func (id clientIdentity) titleVariables() map[string]interface{} {
+	vars := map[string]interface{}{
+		"user":   id.User,
+		"email":  id.Email,
+		"groups": id.Groups,
+		"role":   id.Role,
+	}
+	if id.Certificate != nil {
+		vars["client_cert"] = map[string]interface{}{
+			"subject":              id.Certificate.Subject.String(),
+			"common_name":          id.Certificate.Subject.CommonName,
+			"organizational_units": id.Certificate.Subject.OrganizationalUnit,
+			"organizations":        id.Certificate.Subject.Organization,
+			"emails":               id.Certificate.EmailAddresses,
+			"dns_names":            id.Certificate.DNSNames,
+			"serial":               id.Certificate.SerialNumber.String(),
+		}
+	}
+	return vars
+}
+
+// wrapClientCertAuth rejects clients whose certificate matches neither of
+// the allowed common names nor the allowed organizational units.
+// Empty lists do not restrict clients.
+func (server *Server) wrapClientCertAuth(handler http.Handler, allowedCNs []string, allowedOUs []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cert := verifiedClientCert(r)
+		if cert == nil || !clientCertAllowed(cert, allowedCNs, allowedOUs) {
+			name := ""
+			if cert != nil {
+				name = certificateName(cert)
+			}
+			server.metrics.authFailures.Inc("client_cert")
+			auditEvent(server.options.Auditor, audit.Event{
+				Type:       audit.EventAuthFailure,
+				User:       name,
+				RemoteAddr: r.RemoteAddr,
+				Reason:     "TLS client certificate not allowed",
+			})
+			log.Printf("TLS Client Certificate Rejected: %s (%s)", r.RemoteAddr, name)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func clientCertAllowed(cert *x509.Certificate, allowedCNs []string, allowedOUs []string) bool {
+	if len(allowedCNs) == 0 && len(allowedOUs) == 0 {
+		return true
+	}
+	for _, cn := range allowedCNs {
+		if cert.Subject.CommonName == cn {
+			return true
+		}
+	}
+	for _, allowed := range allowedOUs {
+		for _, ou := range cert.Subject.OrganizationalUnit {
+			if ou == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -15,10 +15,15 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 
+	"github.com/labbs/webtty/audit"
+	"github.com/labbs/webtty/pkg/policy"
+	"github.com/labbs/webtty/pkg/users"
 	"github.com/labbs/webtty/webtty"
 )
 
-func (server *Server) generateHandleWS(ctx context.Context, cancel context.CancelFunc, counter *counter) http.HandlerFunc {
+// generateHandleWS returns the handler of websocket connections of masters.
+// When shadow is true, administrators watch existing sessions with the connections.
+func (server *Server) generateHandleWS(ctx context.Context, cancel context.CancelFunc, counter *counter, shadow bool) http.HandlerFunc {
 	once := new(int64)
 
 	go func() {
@@ -30,7 +35,14 @@ func (server *Server) generateHandleWS(ctx context.Context, cancel context.Cance
 	}()
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		if server.options.Once {
+		// share links are validated before upgrading,
+		// and their uses are counted when the tokens minted with them are redeemed
+		var share *shareLink
+		if !shadow {
+			_, share = requestShareLink(r)
+		}
+
+		if server.options.Once && !shadow {
 			success := atomic.CompareAndSwapInt64(once, 0, 1)
 			if !success {
 				http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
@@ -40,6 +52,15 @@ func (server *Server) generateHandleWS(ctx context.Context, cancel context.Cance
 
 		num := counter.add(1)
 		closeReason := "unknown reason"
+		// reason of the close without details for metrics
+		closeKind := "error"
+		id := requestIdentity(r)
+		id.Share = share
+		auditEvent(server.options.Auditor, audit.Event{
+			Type:       audit.EventConnect,
+			User:       id.User,
+			RemoteAddr: r.RemoteAddr,
+		})
 
 		defer func() {
 			num := counter.done()
@@ -47,15 +68,30 @@ func (server *Server) generateHandleWS(ctx context.Context, cancel context.Cance
 				"Connection closed by %s: %s, connections: %d/%d",
 				closeReason, r.RemoteAddr, num, server.options.MaxConnection,
 			)
-
-			if server.options.Once {
-				cancel()
+			server.metrics.closes.Inc(closeKind)
+			// the identity is updated once the connection is authenticated
+			auditEvent(server.options.Auditor, audit.Event{
+				Type:       audit.EventDisconnect,
+				User:       id.User,
+				RemoteAddr: r.RemoteAddr,
+				Reason:     closeReason,
+			})
+
+			if server.options.Once && !shadow {
+				if server.options.RotateRandomUrl {
+					// accept another client at a new URL
+					server.rotateRandomPath()
+					atomic.StoreInt64(once, 0)
+				} else {
+					cancel()
+				}
 			}
 		}()
 
 		if int64(server.options.MaxConnection) != 0 {
 			if num > server.options.MaxConnection {
 				closeReason = "exceeding max number of connections"
+				closeKind = "max_connection"
 				return
 			}
 		}
@@ -63,6 +99,7 @@ func (server *Server) generateHandleWS(ctx context.Context, cancel context.Cance
 		log.Printf("New client connected: %s, connections: %d/%d", r.RemoteAddr, num, server.options.MaxConnection)
 
 		if r.Method != "GET" {
+			closeKind = "method_not_allowed"
 			http.Error(w, "Method not allowed", 405)
 			return
 		}
@@ -70,32 +107,41 @@ func (server *Server) generateHandleWS(ctx context.Context, cancel context.Cance
 		conn, err := server.upgrader.Upgrade(w, r, nil)
 		if err != nil {
 			closeReason = err.Error()
+			closeKind = "upgrade_failure"
 			return
 		}
 		defer conn.Close()
 
-		err = server.processWSConn(ctx, conn)
+		err = server.processWSConn(ctx, conn, &id, shadow)
 
 		switch err {
 		case ctx.Err():
 			closeReason = "cancelation"
+			closeKind = "cancelation"
 		case webtty.ErrSlaveClosed:
 			closeReason = server.factory.Name()
+			closeKind = "slave"
 		case webtty.ErrMasterClosed:
 			closeReason = "client"
+			closeKind = "client"
 		default:
 			closeReason = fmt.Sprintf("an error: %s", err)
+			if _, ok := err.(*policy.Denial); ok {
+				closeKind = "policy_denied"
+			}
 		}
 	}
 }
 
-func (server *Server) processWSConn(ctx context.Context, conn *websocket.Conn) error {
+func (server *Server) processWSConn(ctx context.Context, conn *websocket.Conn, id *clientIdentity, shadow bool) error {
+	master := newWSWrapper(conn)
+	user := id.User
 
 	typ, initLine, err := conn.ReadMessage()
 	if err != nil {
 		return errors.Wrapf(err, "failed to authenticate websocket connection")
 	}
-	if typ != websocket.TextMessage {
+	if typ != master.messageType {
 		return errors.New("failed to authenticate websocket connection: invalid message type")
 	}
 
@@ -104,12 +150,85 @@ func (server *Server) processWSConn(ctx context.Context, conn *websocket.Conn) e
 	if err != nil {
 		return errors.Wrapf(err, "failed to authenticate websocket connection")
 	}
-	if init.AuthToken != server.options.Credential {
-		return errors.New("failed to authenticate websocket connection")
+	// resume tokens are issued to authenticated viewers of the sessions
+	var grant resumeGrant
+	resumed := false
+	if init.SessionToken != "" && !shadow {
+		if sess, ok := server.sessions.resume(init.SessionToken); ok {
+			grant, resumed = sess.lookupToken(init.SessionToken)
+		}
+	}
+	// shadow connections are authenticated as administrators beforehand
+	authenticated := shadow
+	if !shadow {
+		tok, err := server.wsTokens.redeem(init.AuthToken, id.Origin)
+		authenticated = err == nil
+		if err != nil {
+			if !resumed {
+				server.metrics.authFailures.Inc("websocket")
+				auditEvent(server.options.Auditor, audit.Event{
+					Type:       audit.EventAuthFailure,
+					User:       user,
+					RemoteAddr: conn.RemoteAddr().String(),
+					Reason:     "invalid websocket auth token: " + err.Error(),
+				})
+				return errors.Wrapf(err, "failed to authenticate websocket connection")
+			}
+		} else {
+			// tokens minted with share links only attach to the sessions of the links
+			if err := server.bindShareLink(tok, id); err != nil {
+				server.metrics.authFailures.Inc("share_link")
+				auditEvent(server.options.Auditor, audit.Event{
+					Type:       audit.EventAuthFailure,
+					User:       user,
+					RemoteAddr: conn.RemoteAddr().String(),
+					Reason:     "invalid share link: " + err.Error(),
+				})
+				return errors.Wrapf(err, "failed to authorize share link")
+			}
+			if user == "" {
+				// browsers do not always send credentials on websocket connections
+				user = tok.User
+				id.User = tok.User
+			} else if tok.User != user {
+				server.metrics.authFailures.Inc("websocket")
+				auditEvent(server.options.Auditor, audit.Event{
+					Type:       audit.EventAuthFailure,
+					User:       user,
+					RemoteAddr: conn.RemoteAddr().String(),
+					Reason:     "websocket auth token is minted for another user",
+				})
+				return errors.New("failed to authenticate websocket connection: token is minted for another user")
+			}
+		}
+	}
+	if resumed {
+		// resumed viewers keep the identity authenticated when the token was issued,
+		// as the user claimed by the request is not verified without an auth token
+		if authenticated && id.User != grant.identity.User {
+			return errors.New("failed to resume session: session token is issued to another user")
+		}
+		if grant.identity.Share != nil {
+			if err := server.shareLinks.active(grant.identity.Share.ID); err != nil {
+				return errors.Wrapf(err, "failed to resume session")
+			}
+		}
+		*id = grant.identity
+		user = id.User
+	}
+	authEvent := audit.Event{
+		Type:       audit.EventAuthSuccess,
+		User:       user,
+		RemoteAddr: conn.RemoteAddr().String(),
+	}
+	if id.Share != nil {
+		authEvent.SessionID = id.Share.SessionID
+		authEvent.Reason = "share link " + id.Share.ID
 	}
+	auditEvent(server.options.Auditor, authEvent)
 
 	queryPath := "?"
-	if server.options.PermitArguments && init.Arguments != "" {
+	if init.Arguments != "" {
 		queryPath = init.Arguments
 	}
 
@@ -118,21 +237,91 @@ func (server *Server) processWSConn(ctx context.Context, conn *websocket.Conn) e
 		return errors.Wrapf(err, "failed to parse arguments")
 	}
 	params := query.Query()
-	var slave Slave
-	slave, err = server.factory.New(params)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create backend")
+	// the share link is already validated
+	params.Del("share")
+
+	role, permitWrite := server.userRole(user)
+	id.Role = role
+	if _, ok := server.factory.(ControlFactory); ok {
+		// the input only controls the slaves, such as playing back recordings
+		permitWrite = true
+	}
+	// resume tokens keep whether the viewers they are issued to can write
+	readOnly := !permitWrite || params.Get("readonly") == "true"
+
+	// share links are minted by administrators to bypass the policy
+	if server.policy != nil && !shadow && id.Share == nil {
+		newSession := init.SessionToken == "" && params.Get("session") == ""
+		if err := server.checkPolicy(master, *id, conn.RemoteAddr().String(), params, newSession); err != nil {
+			return err
+		}
+	}
+
+	var sess *session
+	attached := false
+	if shadow {
+		var ok bool
+		sess, ok = server.sessions.get(params.Get("session"))
+		if !ok {
+			return errors.Errorf("failed to shadow session: session `%s` not found", params.Get("session"))
+		}
+		readOnly = true
+	} else if init.SessionToken != "" {
+		var ok bool
+		sess, ok = server.sessions.resume(init.SessionToken)
+		if !ok {
+			return errors.New("failed to resume session: invalid session token")
+		}
+		readOnly = grant.readOnly
+	} else if id.Share != nil {
+		// connections with share links never choose sessions or create new ones
+		var ok bool
+		sess, ok = server.sessions.get(id.Share.SessionID)
+		if !ok {
+			return errors.Errorf("failed to attach to session: session `%s` of the share link not found", id.Share.SessionID)
+		}
+		readOnly = id.Share.readOnly() || !server.options.PermitWrite
+	} else if sessionID := params.Get("session"); sessionID != "" {
+		if !server.options.EnableSessionSharing {
+			return errors.New("failed to attach to session: session sharing is disabled")
+		}
+		var ok bool
+		sess, ok = server.sessions.get(sessionID)
+		if !ok {
+			return errors.Errorf("failed to attach to session: session `%s` not found", sessionID)
+		}
+		if server.options.SharedReadOnly {
+			readOnly = true
+		}
+	} else {
+		if !server.options.PermitArguments {
+			params = server.controlParameters(params)
+		}
+		var slave Slave
+		slave, err = server.factory.New(params)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create backend")
+		}
+		sess, err = server.sessions.create(slave, conn.RemoteAddr().String(), user)
+		if err != nil {
+			slave.Close()
+			return errors.Wrapf(err, "failed to create session")
+		}
+		defer func() {
+			if !attached {
+				sess.close()
+			}
+		}()
 	}
-	defer slave.Close()
 
+	masterVars := id.titleVariables()
+	masterVars["remote_addr"] = conn.RemoteAddr()
 	titleVars := server.titleVariables(
 		[]string{"server", "master", "slave"},
 		map[string]map[string]interface{}{
 			"server": server.options.TitleVariables,
-			"master": map[string]interface{}{
-				"remote_addr": conn.RemoteAddr(),
-			},
-			"slave": slave.WindowTitleVariables(),
+			"master": masterVars,
+			"slave":  sess.slave.WindowTitleVariables(),
 		},
 	)
 
@@ -145,7 +334,7 @@ func (server *Server) processWSConn(ctx context.Context, conn *websocket.Conn) e
 	opts := []webtty.Option{
 		webtty.WithWindowTitle(titleBuf.Bytes()),
 	}
-	if server.options.PermitWrite {
+	if !readOnly {
 		opts = append(opts, webtty.WithPermitWrite())
 	}
 	if server.options.EnableReconnect {
@@ -160,11 +349,30 @@ func (server *Server) processWSConn(ctx context.Context, conn *websocket.Conn) e
 	if server.options.Preferences != nil {
 		opts = append(opts, webtty.WithMasterPreferences(server.options.Preferences))
 	}
+	if master.isBinary() {
+		opts = append(opts, webtty.WithBinaryProtocol())
+	}
+	viewer := sess.newViewer(conn.RemoteAddr().String(), *id, readOnly)
+	viewer.shadow = shadow
+	if (server.options.EnableSessionSharing || server.options.SessionGracePeriod > 0) && !shadow {
+		opts = append(opts, webtty.WithSession(sess.id, viewer.token))
+	}
+	if readOnly {
+		opts = append(opts, webtty.WithWriteDeniedHandler(viewer.denyWrite))
+	}
 
-	tty, err := webtty.New(&wsWrapper{conn}, slave, opts...)
+	tty, err := webtty.New(master, viewer, opts...)
 	if err != nil {
 		return errors.Wrapf(err, "failed to create webtty")
 	}
+	viewer.notify = tty.SendSessionEvent
+
+	err = sess.attach(viewer, init.SessionToken)
+	if err != nil {
+		return errors.Wrapf(err, "failed to attach to session")
+	}
+	attached = true
+	defer sess.detach(viewer)
 
 	err = tty.Run(ctx)
 
@@ -172,6 +380,41 @@ func (server *Server) processWSConn(ctx context.Context, conn *websocket.Conn) e
 }
 
 func (server *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	server.renderIndex(w, r, server.options.Path)
+}
+
+// controlParameters returns the parameters in params that the factory takes to control new slaves,
+// if it is a ControlFactory.
+func (server *Server) controlParameters(params url.Values) url.Values {
+	controls := url.Values{}
+	factory, ok := server.factory.(ControlFactory)
+	if !ok {
+		return controls
+	}
+	for _, name := range factory.ControlParameters() {
+		if values, ok := params[name]; ok {
+			controls[name] = values
+		}
+	}
+	return controls
+}
+
+// userRole returns the role of user in the users file, and whether the user can write
+// input to the PTY. Users not in the file are viewers. PermitWrite is the only condition
+// without the users file.
+func (server *Server) userRole(user string) (string, bool) {
+	if server.users == nil {
+		return "", server.options.PermitWrite
+	}
+	role := users.RoleViewer
+	if u, ok := server.users.Lookup(user); ok && user != "" {
+		role = u.Role
+	}
+	return string(role), server.options.PermitWrite && role.CanWrite()
+}
+
+// renderIndex renders the page of the terminal loading assets under assetsPath.
+func (server *Server) renderIndex(w http.ResponseWriter, r *http.Request, assetsPath string) {
 	titleVars := server.titleVariables(
 		[]string{"server", "master"},
 		map[string]map[string]interface{}{
@@ -189,13 +432,28 @@ func (server *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	indexVars := map[string]interface{}{
-		"title": titleBuf.String(),
-		"path":  server.options.Path,
+	if assetsPath != "/" && !strings.HasSuffix(assetsPath, "/") {
+		assetsPath = assetsPath + "/"
 	}
 
-	if server.options.Path != "/" && !strings.HasSuffix(server.options.Path, "/") {
-		indexVars["path"] = server.options.Path + "/"
+	raw, share := requestShareLink(r)
+	authToken, _, err := server.wsTokens.mint(requestUser(r), requestOrigin(r), share)
+	if err != nil {
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	// assets are requested with the share link the page is opened with
+	query := ""
+	if raw != "" {
+		query = "?" + url.Values{"share": []string{raw}}.Encode()
+	}
+
+	indexVars := map[string]interface{}{
+		"title":     titleBuf.String(),
+		"path":      publicPath(r, assetsPath),
+		"query":     query,
+		"authToken": authToken,
 	}
 
 	tmpl, err := template.New("index").Parse(indexTemplate)
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+type healthStatus struct {
+	Status         string `json:"status"`
+	Listening      bool   `json:"listening"`
+	Draining       bool   `json:"draining"`
+	Connections    int    `json:"connections"`
+	MaxConnections int    `json:"max_connections,omitempty"`
+}
+
+// handleHealthz reports the server is alive, which is true whenever it can respond.
+func (server *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealthStatus(w, http.StatusOK, healthStatus{
+		Status:    "ok",
+		Listening: server.isListening(),
+		Draining:  atomic.LoadInt32(&server.draining) != 0,
+	})
+}
+
+// generateHandleReadyz reports whether the server accepts new connections.
+// The server is not ready when it is draining, any of its listeners is not serving,
+// or the number of connections is at its limit.
+func (server *Server) generateHandleReadyz(counter *counter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := healthStatus{
+			Status:         "ok",
+			Listening:      server.isListening(),
+			Draining:       atomic.LoadInt32(&server.draining) != 0,
+			Connections:    counter.count(),
+			MaxConnections: server.options.MaxConnection,
+		}
+
+		code := http.StatusOK
+		switch {
+		case status.Draining:
+			status.Status = "draining"
+			code = http.StatusServiceUnavailable
+		case !status.Listening:
+			status.Status = "not_listening"
+			code = http.StatusServiceUnavailable
+		case status.MaxConnections > 0 && status.Connections >= status.MaxConnections:
+			status.Status = "full"
+			code = http.StatusServiceUnavailable
+		}
+
+		writeHealthStatus(w, code, status)
+	}
+}
+
+// isListening returns true if all the listeners of the server are serving requests.
+func (server *Server) isListening() bool {
+	return atomic.LoadInt32(&server.listening) == int32(len(server.listeners)) && len(server.listeners) > 0
+}
+
+func writeHealthStatus(w http.ResponseWriter, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name        string
+		listening   int32
+		draining    int32
+		connections int
+		wantCode    int
+		wantStatus  string
+	}{
+		{name: "ready", listening: 1, wantCode: http.StatusOK, wantStatus: "ok"},
+		{name: "not listening", listening: 0, wantCode: http.StatusServiceUnavailable, wantStatus: "not_listening"},
+		{name: "draining", listening: 1, draining: 1, wantCode: http.StatusServiceUnavailable, wantStatus: "draining"},
+		{name: "full", listening: 1, connections: 1, wantCode: http.StatusServiceUnavailable, wantStatus: "full"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{
+				options:   &Options{MaxConnection: 1},
+				listeners: []net.Listener{nil},
+				listening: tt.listening,
+				draining:  tt.draining,
+			}
+			counter := newCounter(0)
+			counter.add(tt.connections)
+
+			w := httptest.NewRecorder()
+			server.generateHandleReadyz(counter)(w, httptest.NewRequest("GET", "/readyz", nil))
+
+			var status healthStatus
+			if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+				t.Fatalf("failed to decode status: %v", err)
+			}
+			if w.Code != tt.wantCode || status.Status != tt.wantStatus {
+				t.Errorf("readyz = %d %q, want %d %q", w.Code, status.Status, tt.wantCode, tt.wantStatus)
+			}
+			if status.Listening != (tt.listening > 0) {
+				t.Errorf("listening = %t, want %t", status.Listening, tt.listening > 0)
+			}
+		})
+	}
+}
@@ -3,4 +3,6 @@ package server
 type InitMessage struct {
 	Arguments string `json:"Arguments,omitempty"`
 	AuthToken string `json:"AuthToken,omitempty"`
+	// token to resume a session the client was previously attached to
+	SessionToken string `json:"SessionToken,omitempty"`
 }
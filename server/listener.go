@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// prefix of addresses of Unix domain sockets
+	unixAddressPrefix = "unix:"
+	// address to use sockets passed by systemd
+	systemdAddress = "systemd"
+	// first file descriptor passed by systemd
+	listenFdsStart = 3
+)
+
+// listen opens the listeners for the configured address, which is one of
+//
+//	unix:{path}  a Unix domain socket created with SocketMode
+//	systemd      sockets passed by systemd socket activation
+//	{host}       a TCP socket on the host and Port
+func (server *Server) listen() ([]net.Listener, error) {
+	address := server.options.Address
+
+	switch {
+	case strings.HasPrefix(address, unixAddressPrefix):
+		listener, err := listenUnix(strings.TrimPrefix(address, unixAddressPrefix), server.options.SocketMode)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{listener}, nil
+	case address == systemdAddress:
+		return listenSystemd()
+	default:
+		listener, err := net.Listen("tcp", net.JoinHostPort(address, server.options.Port))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to listen on %s:%s", address, server.options.Port)
+		}
+		return []net.Listener{listener}, nil
+	}
+}
+
+// listenUnix creates a Unix domain socket at path with the permissions in mode,
+// which is an octal number such as `0660`.
+// A socket left at the path by a previous process is removed.
+func listenUnix(path string, mode string) (net.Listener, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid socket mode `%s`", mode)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, errors.Errorf("failed to listen on %s: file exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.Errorf("failed to listen on %s: socket is in use", path)
+		}
+		os.Remove(path)
+	}
+
+	// create the socket in a private directory and move it to the path
+	// once its permissions are set, so that nobody connects to it in between
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".webtty-")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", path)
+	}
+	defer os.RemoveAll(dir)
+
+	tmpPath := filepath.Join(dir, "socket")
+	listener, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on %s", path)
+	}
+	// the socket is removed by unixListener at the path
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	err = os.Chmod(tmpPath, os.FileMode(perm))
+	if err != nil {
+		listener.Close()
+		return nil, errors.Wrapf(err, "failed to set permissions of %s", path)
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		listener.Close()
+		return nil, errors.Wrapf(err, "failed to listen on %s", path)
+	}
+
+	return &unixListener{UnixListener: listener.(*net.UnixListener), path: path}, nil
+}
+
+// unixListener is a Unix domain socket moved to path, which is removed on close.
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (listener *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: listener.path, Net: "unix"}
+}
+
+func (listener *unixListener) Close() error {
+	err := listener.UnixListener.Close()
+	os.Remove(listener.path)
+	return err
+}
+
+// listenSystemd returns the sockets passed by systemd socket activation.
+func listenSystemd() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, errors.New("no socket passed by systemd: LISTEN_PID is not set for this process")
+	}
+	num, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || num <= 0 {
+		return nil, errors.New("no socket passed by systemd: LISTEN_FDS is not set")
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	// not to be inherited by the commands
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, num)
+	for i := 0; i < num; i++ {
+		fd := listenFdsStart + i
+		syscall.CloseOnExec(fd)
+
+		name := fmt.Sprintf("LISTEN_FD_%d", fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, errors.Wrapf(err, "failed to use socket %s passed by systemd", name)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "webtty.sock")
+
+	listener, err := listenUnix(path, "0660")
+	if err != nil {
+		t.Fatalf("listenUnix() = %v", err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatalf("Lstat() = %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0660 {
+		t.Errorf("socket mode = %s, want socket with 0660", info.Mode())
+	}
+	if got := listener.Addr().String(); got != path {
+		t.Errorf("Addr() = %s, want %s", got, path)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("%d files left in the directory, want only the socket", len(entries))
+	}
+
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	conn.Close()
+
+	// a socket in use is not replaced
+	if _, err := listenUnix(path, "0660"); err == nil {
+		t.Errorf("listenUnix() on a socket in use succeeded")
+	}
+
+	listener.Close()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("socket is not removed on close: %v", err)
+	}
+}
+
+func TestListenUnixRejects(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0600)
+	if _, err := listenUnix(file, "0660"); err == nil {
+		t.Errorf("listenUnix() on a regular file succeeded")
+	}
+	if _, err := listenUnix(filepath.Join(dir, "sock"), "0x660"); err == nil {
+		t.Errorf("listenUnix() with an invalid mode succeeded")
+	}
+}
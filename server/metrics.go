@@ -0,0 +1,59 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/labbs/webtty/pkg/metrics"
+)
+
+// serverMetrics are the metrics of a Server.
+type serverMetrics struct {
+	registry *metrics.Registry
+
+	sessionsCreated *metrics.Counter
+	// bytes transferred labeled by direction, `in` for input and `out` for output
+	bytes *metrics.CounterVec
+	// closed websocket connections labeled by reason
+	closes *metrics.CounterVec
+	// failed authentications labeled by method
+	authFailures  *metrics.CounterVec
+	slaveLifetime *metrics.Histogram
+
+	// counter of connections of the running server
+	counter *counter
+	mutex   sync.Mutex
+}
+
+func newServerMetrics() *serverMetrics {
+	registry := metrics.NewRegistry()
+	m := &serverMetrics{
+		registry: registry,
+
+		sessionsCreated: registry.NewCounter("gotty_sessions_created_total", "Number of sessions created."),
+		bytes:           registry.NewCounterVec("gotty_bytes_total", "Bytes transferred between clients and slaves by direction.", "direction"),
+		closes:          registry.NewCounterVec("gotty_websocket_closed_total", "Number of closed websocket connections by reason.", "reason"),
+		authFailures:    registry.NewCounterVec("gotty_auth_failures_total", "Number of failed authentications by method.", "method"),
+		slaveLifetime: registry.NewHistogram(
+			"gotty_slave_lifetime_seconds", "Lifetime of slaves in seconds.",
+			[]float64{1, 10, 60, 300, 900, 3600, 4 * 3600, 24 * 3600},
+		),
+	}
+	registry.NewGaugeFunc("gotty_connections_active", "Number of active websocket connections.", func() float64 {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		if m.counter == nil {
+			return 0
+		}
+		return float64(m.counter.count())
+	})
+
+	return m
+}
+
+func (m *serverMetrics) setCounter(counter *counter) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.counter = counter
+}
@@ -1,10 +1,15 @@
 package server
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/base64"
 	"log"
 	"net/http"
 	"strings"
+
+	"github.com/labbs/webtty/audit"
+	"github.com/labbs/webtty/pkg/users"
 )
 
 func (server *Server) wrapLogger(handler http.Handler) http.Handler {
@@ -23,12 +28,15 @@ func (server *Server) wrapHeaders(handler http.Handler) http.Handler {
 	})
 }
 
-func (server *Server) wrapBasicAuth(handler http.Handler, credential string) http.Handler {
+// basicAuthUserKey is the context key of the user name verified with basic auth.
+type basicAuthUserKey struct{}
+
+func (server *Server) wrapBasicAuth(handler http.Handler, check func(payload string) bool, realm string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
 
 		if len(token) != 2 || strings.ToLower(token[0]) != "basic" {
-			w.Header().Set("WWW-Authenticate", `Basic realm="GoTTY"`)
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`"`)
 			http.Error(w, "Bad Request", http.StatusUnauthorized)
 			return
 		}
@@ -39,13 +47,43 @@ func (server *Server) wrapBasicAuth(handler http.Handler, credential string) htt
 			return
 		}
 
-		if credential != string(payload) {
-			w.Header().Set("WWW-Authenticate", `Basic realm="GoTTY"`)
+		if !check(string(payload)) {
+			server.metrics.authFailures.Inc("basic")
+			auditEvent(server.options.Auditor, audit.Event{
+				Type:       audit.EventAuthFailure,
+				User:       strings.SplitN(string(payload), ":", 2)[0],
+				RemoteAddr: r.RemoteAddr,
+				Reason:     "invalid basic auth credential",
+			})
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+realm+`"`)
 			http.Error(w, "authorization failed", http.StatusUnauthorized)
 			return
 		}
 
 		log.Printf("Basic Authentication Succeeded: %s", r.RemoteAddr)
-		handler.ServeHTTP(w, r)
+		name, _, _ := strings.Cut(string(payload), ":")
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), basicAuthUserKey{}, name)))
 	})
 }
+
+// credentialChecker returns a function to check basic auth payloads formatted as
+// name:password against credential, in constant time not to leak it by timing.
+func credentialChecker(credential string) func(payload string) bool {
+	return func(payload string) bool {
+		return subtle.ConstantTimeCompare([]byte(payload), []byte(credential)) == 1
+	}
+}
+
+// usersChecker returns a function to check basic auth payloads against the users file.
+func usersChecker(file *users.File) func(payload string) bool {
+	return func(payload string) bool {
+		name, password, _ := strings.Cut(payload, ":")
+		_, ok := file.Authenticate(name, password)
+		return ok
+	}
+}
+
+// requestUser returns the name of the user authenticated for the request, if any.
+func requestUser(r *http.Request) string {
+	return requestIdentity(r).User
+}
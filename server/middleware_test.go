@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCredentialChecker(t *testing.T) {
+	check := credentialChecker("user:pass")
+	for payload, want := range map[string]bool{
+		"user:pass":  true,
+		"user:pas":   false,
+		"user:passx": false,
+		"User:pass":  false,
+		"":           false,
+	} {
+		if got := check(payload); got != want {
+			t.Errorf("check(%q) = %t, want %t", payload, got, want)
+		}
+	}
+}
+
+func TestWrapBasicAuth(t *testing.T) {
+	server, err := New(nil, &Options{Path: "/"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	var user string
+	handler := server.wrapBasicAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = requestUser(r)
+	}), credentialChecker("alice:pass"), "test")
+
+	tests := []struct {
+		name       string
+		credential []string
+		wantStatus int
+		wantUser   string
+	}{
+		{name: "valid", credential: []string{"alice", "pass"}, wantStatus: http.StatusOK, wantUser: "alice"},
+		{name: "invalid password", credential: []string{"alice", "wrong"}, wantStatus: http.StatusUnauthorized},
+		{name: "no credential", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user = ""
+			r := httptest.NewRequest("GET", "http://example.com/", nil)
+			if tt.credential != nil {
+				r.SetBasicAuth(tt.credential[0], tt.credential[1])
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus || user != tt.wantUser {
+				t.Errorf("served %d for user %q, want %d for user %q", w.Code, user, tt.wantStatus, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestRequestIdentityIgnoresUnverifiedBasicAuth(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/ws", nil)
+	r.SetBasicAuth("admin", "anything")
+	if id := requestIdentity(r); id.User != "" {
+		t.Errorf("requestIdentity() named the user %q after the basic auth header not verified", id.User)
+	}
+
+	// tokens minted without basic auth enabled are not bound to the user claimed
+	server, err := New(nil, &Options{Path: "/"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := httptest.NewServer(server.setupHandlers(ctx, cancel, "/", newCounter(0)))
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/token", nil)
+	req.SetBasicAuth("admin", "anything")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /token = %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	tok, err := server.wsTokens.redeem(body.Token, strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("redeem() = %v", err)
+	}
+	if tok.User != "" {
+		t.Errorf("token minted for user %q claimed by the basic auth header", tok.User)
+	}
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/audit"
+	"github.com/labbs/webtty/pkg/oidc"
+	"github.com/labbs/webtty/pkg/token"
+)
+
+const (
+	sessionCookieName = "gotty_session"
+	oidcCookieName    = "gotty_oidc"
+	// oidcLoginTimeout is the time users have to log in at the issuer
+	oidcLoginTimeout = 10 * time.Minute
+)
+
+// oidcAuth authenticates users with the OpenID Connect authorization code flow
+// and keeps them logged in with a signed session cookie.
+type oidcAuth struct {
+	provider *oidc.Provider
+	options  *Options
+	// cookiePath is the path the cookies are sent to
+	cookiePath string
+	sessions   *token.Signer
+	logins     *token.Signer
+}
+
+// oidcUser is the identity of a logged in user stored in the session cookie.
+type oidcUser struct {
+	User   string   `json:"u"`
+	Email  string   `json:"e,omitempty"`
+	Groups []string `json:"g,omitempty"`
+}
+
+// oidcLogin is the state of a login in progress stored in a cookie.
+type oidcLogin struct {
+	State        string `json:"s"`
+	Nonce        string `json:"n"`
+	CodeVerifier string `json:"v"`
+	RedirectURL  string `json:"r"`
+	Next         string `json:"x"`
+}
+
+type oidcUserKey struct{}
+
+func newOIDCAuth(options *Options, cookiePath string, signer *token.Signer) (*oidcAuth, error) {
+	provider, err := oidc.NewProvider(oidc.Config{
+		Issuer:       options.OIDCIssuer,
+		ClientID:     options.OIDCClientID,
+		ClientSecret: options.OIDCClientSecret,
+		Scopes:       options.OIDCScopes,
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to setup OpenID Connect")
+	}
+	if options.SecretKey == "" {
+		log.Printf("No secret key is given, users have to log in again after restarts")
+	}
+	return &oidcAuth{
+		provider:   provider,
+		options:    options,
+		cookiePath: cookiePath,
+		sessions:   signer.Derive("session"),
+		logins:     signer.Derive("oidc-login"),
+	}, nil
+}
+
+// wrapOIDC serves handler to logged in users with their identity in the request context.
+// Others are sent to the login page if they request pages, or are rejected.
+func (server *Server) wrapOIDC(handler http.Handler, loginPath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user oidcUser
+		if cookie, err := r.Cookie(sessionCookieName); err == nil {
+			if _, err := server.oidc.sessions.Verify(cookie.Value, &user); err == nil {
+				handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), oidcUserKey{}, user)))
+				return
+			}
+		}
+
+		if r.Method == "GET" && r.Header.Get("Upgrade") == "" {
+			next := publicPath(r, r.URL.Path)
+			if r.URL.RawQuery != "" {
+				next += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, publicPath(r, loginPath)+"?next="+url.QueryEscape(next), http.StatusFound)
+			return
+		}
+		server.metrics.authFailures.Inc("oidc")
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+	})
+}
+
+// handleOIDCLogin sends users to the authorization endpoint of the issuer.
+func (server *Server) handleOIDCLogin(callbackPath string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		redirectURL := server.options.OIDCRedirectURL
+		if redirectURL == "" {
+			scheme := "http"
+			if r.TLS != nil {
+				scheme = "https"
+			}
+			redirectURL = scheme + "://" + r.Host + publicPath(r, callbackPath)
+		}
+
+		login := oidcLogin{
+			State:        randomToken(),
+			Nonce:        randomToken(),
+			CodeVerifier: randomToken() + randomToken(),
+			RedirectURL:  redirectURL,
+			Next:         localPath(r.URL.Query().Get("next"), publicPath(r, server.oidc.cookiePath)),
+		}
+		authURL, err := server.oidc.provider.AuthCodeURL(r.Context(), redirectURL, login.State, login.Nonce, login.CodeVerifier)
+		if err != nil {
+			log.Printf("OpenID Connect login failed: %s", err)
+			http.Error(w, "OpenID Connect issuer is not available", http.StatusBadGateway)
+			return
+		}
+		value, err := server.oidc.logins.Sign(login, time.Now().Add(oidcLoginTimeout))
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		server.setCookie(w, oidcCookieName, value, oidcLoginTimeout)
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+// handleOIDCCallback completes the login with the authorization code issued to users.
+func (server *Server) handleOIDCCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		fail := func(reason string, user string) {
+			server.metrics.authFailures.Inc("oidc")
+			auditEvent(server.options.Auditor, audit.Event{
+				Type:       audit.EventAuthFailure,
+				User:       user,
+				RemoteAddr: r.RemoteAddr,
+				Reason:     reason,
+			})
+			log.Printf("OpenID Connect login failed: %s: %s", r.RemoteAddr, reason)
+			http.Error(w, "login failed: "+reason, http.StatusForbidden)
+		}
+
+		var login oidcLogin
+		cookie, err := r.Cookie(oidcCookieName)
+		if err != nil {
+			fail("no login in progress", "")
+			return
+		}
+		if _, err := server.oidc.logins.Verify(cookie.Value, &login); err != nil {
+			fail("login expired", "")
+			return
+		}
+		server.setCookie(w, oidcCookieName, "", -1)
+		if query.Get("state") != login.State {
+			fail("state mismatch", "")
+			return
+		}
+		if e := query.Get("error"); e != "" {
+			fail("issuer returned "+e, "")
+			return
+		}
+
+		claims, err := server.oidc.provider.Exchange(r.Context(), login.RedirectURL, query.Get("code"), login.CodeVerifier, login.Nonce)
+		if err != nil {
+			fail(err.Error(), "")
+			return
+		}
+		user, reason := server.oidcUserFromClaims(claims)
+		if reason != "" {
+			fail(reason, user.User)
+			return
+		}
+
+		lifetime := time.Duration(server.options.OIDCSessionLifetime) * time.Second
+		value, err := server.oidc.sessions.Sign(user, time.Now().Add(lifetime))
+		if err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		server.setCookie(w, sessionCookieName, value, lifetime)
+		auditEvent(server.options.Auditor, audit.Event{
+			Type:       audit.EventAuthSuccess,
+			User:       user.User,
+			RemoteAddr: r.RemoteAddr,
+		})
+		log.Printf("OpenID Connect login succeeded: %s as %s", r.RemoteAddr, user.User)
+		http.Redirect(w, r, login.Next, http.StatusFound)
+	}
+}
+
+// handleOIDCLogout forgets the session cookie.
+func (server *Server) handleOIDCLogout(w http.ResponseWriter, r *http.Request) {
+	server.setCookie(w, sessionCookieName, "", -1)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("logged out\n"))
+}
+
+// oidcUserFromClaims returns the identity of the user in claims,
+// with the reason of the rejection if the user is not allowed.
+func (server *Server) oidcUserFromClaims(claims oidc.Claims) (oidcUser, string) {
+	user := oidcUser{
+		User:   claims.String(server.options.OIDCUserClaim),
+		Email:  claims.String("email"),
+		Groups: claims.Strings(server.options.OIDCGroupsClaim),
+	}
+	if user.User == "" {
+		user.User = claims.String("sub")
+	}
+
+	if len(server.options.OIDCAllowedEmails) == 0 && len(server.options.OIDCAllowedGroups) == 0 {
+		return user, ""
+	}
+	// unverified emails can be chosen by users at some issuers
+	if _, ok := claims["email_verified"]; !ok || claims.Bool("email_verified") {
+		for _, allowed := range server.options.OIDCAllowedEmails {
+			if strings.EqualFold(user.Email, allowed) {
+				return user, ""
+			}
+		}
+	}
+	for _, allowed := range server.options.OIDCAllowedGroups {
+		for _, group := range user.Groups {
+			if group == allowed {
+				return user, ""
+			}
+		}
+	}
+	return user, "user is not allowed"
+}
+
+func (server *Server) setCookie(w http.ResponseWriter, name string, value string, maxAge time.Duration) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     server.oidc.cookiePath,
+		Secure:   server.options.EnableTLS,
+		HttpOnly: true,
+		// sent on the redirection back from the issuer
+		SameSite: http.SameSiteLaxMode,
+	}
+	if maxAge < 0 {
+		cookie.MaxAge = -1
+	} else {
+		cookie.MaxAge = int(maxAge / time.Second)
+	}
+	http.SetCookie(w, cookie)
+}
+
+// localPath returns next if it is a path on this server, otherwise fallback.
+func localPath(next string, fallback string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.Contains(next, "\\") {
+		return fallback
+	}
+	return next
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
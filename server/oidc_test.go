@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labbs/webtty/audit"
+)
+
+// testAuditor keeps the events audited.
+type testAuditor struct {
+	mutex  sync.Mutex
+	events []audit.Event
+}
+
+func (ta *testAuditor) Audit(event audit.Event) error {
+	ta.mutex.Lock()
+	defer ta.mutex.Unlock()
+	ta.events = append(ta.events, event)
+	return nil
+}
+
+func (ta *testAuditor) last() audit.Event {
+	ta.mutex.Lock()
+	defer ta.mutex.Unlock()
+	if len(ta.events) == 0 {
+		return audit.Event{}
+	}
+	return ta.events[len(ta.events)-1]
+}
+
+func TestOIDCCallbackChecksState(t *testing.T) {
+	// the issuer rejects any code, which is only requested when the state matches
+	var exchanged int32
+	mux := http.NewServeMux()
+	issuer := httptest.NewServer(mux)
+	defer issuer.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.URL,
+			"authorization_endpoint": issuer.URL + "/authorize",
+			"token_endpoint":         issuer.URL + "/token",
+			"jwks_uri":               issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&exchanged, 1)
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+	})
+
+	auditor := &testAuditor{}
+	server, err := New(nil, &Options{
+		Path:                "/",
+		OIDCIssuer:          issuer.URL,
+		OIDCClientID:        "webtty",
+		OIDCSessionLifetime: 3600,
+		Auditor:             auditor,
+	})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	login, err := server.oidc.logins.Sign(oidcLogin{
+		State:        "state-1",
+		Nonce:        "nonce-1",
+		CodeVerifier: "verifier-1",
+		RedirectURL:  "http://webtty.example.com/oidc/callback",
+		Next:         "/",
+	}, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Sign() = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		cookie        string
+		query         string
+		wantReason    string
+		wantExchanged bool
+	}{
+		{name: "no login", query: "?state=state-1&code=c", wantReason: "no login in progress"},
+		{name: "forged login", cookie: "forged", query: "?state=state-1&code=c", wantReason: "login expired"},
+		{name: "state mismatch", cookie: login, query: "?state=state-2&code=c", wantReason: "state mismatch"},
+		{name: "no state", cookie: login, query: "?code=c", wantReason: "state mismatch"},
+		{name: "state matches", cookie: login, query: "?state=state-1&code=c", wantExchanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&exchanged, 0)
+			r := httptest.NewRequest("GET", "/oidc/callback"+tt.query, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: oidcCookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			server.handleOIDCCallback()(w, r)
+
+			// the code is rejected by the issuer in any case
+			if w.Code != http.StatusForbidden {
+				t.Errorf("callback responded %d, want %d", w.Code, http.StatusForbidden)
+			}
+			event := auditor.last()
+			if event.Type != audit.EventAuthFailure || (tt.wantReason != "" && event.Reason != tt.wantReason) {
+				t.Errorf("audited %s %q, want %s %q", event.Type, event.Reason, audit.EventAuthFailure, tt.wantReason)
+			}
+			if got := atomic.LoadInt32(&exchanged) > 0; got != tt.wantExchanged {
+				t.Errorf("code exchanged: %t, want %t", got, tt.wantExchanged)
+			}
+		})
+	}
+}
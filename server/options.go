@@ -2,44 +2,100 @@ package server
 
 import (
 	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/audit"
+	"github.com/labbs/webtty/pkg/redact"
+	"github.com/labbs/webtty/webtty"
 )
 
 type Options struct {
-	ConfigFile          string
-	Address             string
-	Port                string
-	Path                string
-	PermitWrite         bool
-	EnableBasicAuth     bool
-	Credential          string
-	EnableRandomUrl     bool
-	RandomUrlLength     int
-	EnableTLS           bool
-	TLSCrtFile          string
-	TLSKeyFile          string
-	EnableTLSClientAuth bool
-	TLSCACrtFile        string
-	IndexFile           string
-	TitleFormat         string
-	EnableReconnect     bool
-	ReconnectTime       int
-	MaxConnection       int
-	Once                bool
-	Timeout             int
-	PermitArguments     bool
-	Preferences         *HtermPrefernces
-	Width               int
-	Height              int
-	WSOrigin            string
-	Term                string
+	ConfigFile           string
+	Address              string
+	Port                 string
+	SocketMode           string
+	Path                 string
+	PermitWrite          bool
+	EnableBasicAuth      bool
+	Credential           string
+	UsersFile            string
+	PolicyFile           string
+	EnableRandomUrl      bool
+	RandomUrlLength      int
+	RotateRandomUrl      bool
+	EnableTLS            bool
+	TLSCrtFile           string
+	TLSKeyFile           string
+	TLSSelfSigned        bool
+	TLSReload            bool
+	EnableTLSClientAuth  bool
+	TLSCACrtFile         string
+	TLSClientAllowedCNs  []string
+	TLSClientAllowedOUs  []string
+	IndexFile            string
+	TitleFormat          string
+	EnableReconnect      bool
+	ReconnectTime        int
+	MaxConnection        int
+	Once                 bool
+	Timeout              int
+	PermitArguments      bool
+	Preferences          *HtermPrefernces
+	Width                int
+	Height               int
+	WSOrigin             string
+	Term                 string
+	EnableSessionSharing bool
+	SharedReadOnly       bool
+	SessionGracePeriod   int
+	ScrollbackSize       int
+	EnableMetrics        bool
+	MetricsAddress       string
+	AdminCredential      string
+	SecretKey            string
+	OIDCIssuer           string
+	OIDCClientID         string
+	OIDCClientSecret     string
+	OIDCRedirectURL      string
+	OIDCScopes           []string
+	OIDCUserClaim        string
+	OIDCGroupsClaim      string
+	OIDCAllowedEmails    []string
+	OIDCAllowedGroups    []string
+	OIDCSessionLifetime  int
 
 	TitleVariables map[string]interface{}
+	Recorder       webtty.Recorder
+	// Redactor masks secrets in output sent to masters if not nil
+	Redactor *redact.Redactor
+	// Auditor records events of connections and sessions if not nil
+	Auditor audit.Auditor
 }
 
 func (options *Options) Validate() error {
 	if options.EnableTLSClientAuth && !options.EnableTLS {
 		return errors.New("TLS client authentication is enabled, but TLS is not enabled")
 	}
+	if options.UsersFile != "" && !options.EnableBasicAuth && options.OIDCIssuer == "" && !options.EnableTLSClientAuth {
+		return errors.New("users file is given, but no authentication is enabled")
+	}
+	if options.OIDCIssuer != "" && options.EnableBasicAuth {
+		return errors.New("OpenID Connect and basic authentication are exclusive")
+	}
+	if options.OIDCIssuer != "" && options.OIDCSessionLifetime <= 0 {
+		return errors.New("OpenID Connect session lifetime is not positive")
+	}
+	if options.EnableRandomUrl && options.RandomUrlLength <= 0 {
+		return errors.New("random URL is enabled, but the length is not positive")
+	}
+	if options.RotateRandomUrl && !(options.EnableRandomUrl && options.Once) {
+		return errors.New("random URL rotation requires both random URL and once options")
+	}
+	if (options.TLSSelfSigned || options.TLSReload) && !options.EnableTLS {
+		return errors.New("TLS certificate options are given, but TLS is not enabled")
+	}
+	if (len(options.TLSClientAllowedCNs) > 0 || len(options.TLSClientAllowedOUs) > 0) && !options.EnableTLSClientAuth {
+		return errors.New("TLS client certificate allow-lists are given, but TLS client authentication is not enabled")
+	}
 	return nil
 }
 
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/url"
+	"sort"
+
+	"github.com/labbs/webtty/audit"
+	"github.com/labbs/webtty/pkg/policy"
+)
+
+// commandFactory is implemented by factories running a command,
+// whose command is checked against the policy.
+type commandFactory interface {
+	Command() (string, []string)
+}
+
+// checkPolicy returns a *policy.Denial if the policy does not allow the client
+// to use params, which creates a new session with the arguments in params if
+// newSession is true. Denials are reported to the master.
+func (server *Server) checkPolicy(master *wsWrapper, id clientIdentity, remoteAddr string, params url.Values, newSession bool) error {
+	req := policy.Request{
+		User:   id.User,
+		Role:   id.Role,
+		Groups: id.Groups,
+	}
+	for name := range params {
+		req.Params = append(req.Params, name)
+	}
+	sort.Strings(req.Params)
+	if factory, ok := server.factory.(commandFactory); ok {
+		req.Command, _ = factory.Command()
+		if newSession && server.options.PermitArguments {
+			req.Args = params["arg"]
+		}
+	}
+
+	err := server.policy.Check(req)
+	if err == nil {
+		return nil
+	}
+	reason := err.Error()
+	if denial, ok := err.(*policy.Denial); ok {
+		reason = denial.Reason
+	}
+	auditEvent(server.options.Auditor, audit.Event{
+		Type:       audit.EventPolicyDenied,
+		User:       id.User,
+		RemoteAddr: remoteAddr,
+		Command:    req.Command,
+		Argv:       req.Args,
+		Reason:     reason,
+	})
+	master.deny(err.Error())
+	return err
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/labbs/webtty/pkg/randomstring"
+)
+
+// randomPath is the secret path segment clients have to know to access the server.
+type randomPath struct {
+	length int
+
+	mutex sync.RWMutex
+	value string
+}
+
+func newRandomPath(length int) *randomPath {
+	return &randomPath{
+		length: length,
+		value:  randomstring.Generate(length),
+	}
+}
+
+func (rp *randomPath) get() string {
+	rp.mutex.RLock()
+	defer rp.mutex.RUnlock()
+	return rp.value
+}
+
+// rotate replaces the segment with a new one,
+// which makes URLs with the previous one unavailable.
+func (rp *randomPath) rotate() string {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	rp.value = randomstring.Generate(rp.length)
+	return rp.value
+}
+
+type publicPathKey struct{}
+
+// publicPrefix maps the path prefix handlers are mounted at to the one clients see.
+type publicPrefix struct {
+	internal string
+	public   string
+}
+
+// wrapRandomPath serves handler, which is mounted at pathPrefix, under the
+// random path segment following pathPrefix. Requests to other paths are not
+// found, except probes of orchestrators, which do not know the segment.
+func (server *Server) wrapRandomPath(handler http.Handler, pathPrefix string) http.Handler {
+	prefix := pathPrefix
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := prefix + server.randomPath.get()
+
+		switch {
+		case r.URL.Path == secret:
+			target := secret + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, secret+"/"):
+			rest := strings.TrimPrefix(r.URL.Path, secret+"/")
+			u := *r.URL
+			u.Path = prefix + rest
+			if rest == "" {
+				u.Path = pathPrefix
+			}
+			u.RawPath = ""
+			ctx := context.WithValue(r.Context(), publicPathKey{}, publicPrefix{internal: prefix, public: secret + "/"})
+			r2 := r.WithContext(ctx)
+			r2.URL = &u
+			handler.ServeHTTP(w, r2)
+		case r.URL.Path == prefix+"healthz" || r.URL.Path == prefix+"readyz":
+			handler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// publicPath returns the path clients use to access path in the request.
+func publicPath(r *http.Request, path string) string {
+	pp, ok := r.Context().Value(publicPathKey{}).(publicPrefix)
+	if !ok || !strings.HasPrefix(path, pp.internal) {
+		return path
+	}
+	return pp.public + strings.TrimPrefix(path, pp.internal)
+}
+
+// rotateRandomPath replaces the random path segment and logs the new URLs.
+func (server *Server) rotateRandomPath() {
+	server.randomPath.rotate()
+	log.Printf("Random URL rotated")
+	server.logURLs()
+}
+
+// urlPath returns the path of the terminal page clients have to access.
+func (server *Server) urlPath() string {
+	path := server.options.Path
+	if server.randomPath == nil {
+		return path
+	}
+	if !strings.HasSuffix(path, "/") {
+		path = path + "/"
+	}
+	return path + server.randomPath.get() + "/"
+}
+
+// logURLs logs the URLs of the terminal page for every address the server listens at.
+// Listeners at unspecified addresses are reachable with any address of the interfaces.
+func (server *Server) logURLs() {
+	scheme := "http"
+	if server.options.EnableTLS {
+		scheme = "https"
+	}
+	path := server.urlPath()
+
+	for _, listener := range server.listeners {
+		addr := listener.Addr()
+		if addr.Network() == "unix" {
+			log.Printf("URL: %s on unix socket %s", path, addr.String())
+			continue
+		}
+		host, port, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			continue
+		}
+		hosts := []string{host}
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			hosts = listAddresses()
+		}
+		for _, h := range hosts {
+			if ip := net.ParseIP(h); ip != nil && ip.IsLinkLocalUnicast() {
+				continue
+			}
+			u := url.URL{Scheme: scheme, Host: net.JoinHostPort(h, port), Path: path}
+			log.Printf("URL: %s", u.String())
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/webtty"
+)
+
+// recordedSlave is a Slave recording its output, input and resize events.
+type recordedSlave struct {
+	*webtty.RecordedSlave
+	slave Slave
+}
+
+// newRecordedSlave starts recording the slave of a session with the recorder.
+func newRecordedSlave(slave Slave, recorder webtty.Recorder, id string, remoteAddr string, startTime time.Time, options *Options) (*recordedSlave, error) {
+	info := webtty.RecordingInfo{
+		SessionID:  id,
+		RemoteAddr: remoteAddr,
+		StartTime:  startTime,
+		Width:      80,
+		Height:     24,
+		Term:       options.Term,
+	}
+	if options.Width > 0 {
+		info.Width = options.Width
+	}
+	if options.Height > 0 {
+		info.Height = options.Height
+	}
+	vars := slave.WindowTitleVariables()
+	if command, ok := vars["command"]; ok {
+		info.Command = fmt.Sprint(command)
+	}
+	if argv, ok := vars["argv"].([]string); ok {
+		info.Argv = argv
+	}
+
+	session, err := recorder.OpenSession(info)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to start recording")
+	}
+
+	return &recordedSlave{
+		RecordedSlave: webtty.NewRecordedSlave(slave, session),
+		slave:         slave,
+	}, nil
+}
+
+func (slave *recordedSlave) Close() error {
+	err := slave.slave.Close()
+	slave.RecordedSlave.Close()
+	return err
+}
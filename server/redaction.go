@@ -0,0 +1,22 @@
+package server
+
+import (
+	"github.com/labbs/webtty/pkg/redact"
+)
+
+// redactedSlave is a Slave masking secrets in its output sent to masters.
+type redactedSlave struct {
+	Slave
+	output *redact.Reader
+}
+
+func newRedactedSlave(slave Slave, redactor *redact.Redactor) *redactedSlave {
+	return &redactedSlave{
+		Slave:  slave,
+		output: redact.NewReader(slave, redactor),
+	}
+}
+
+func (slave *redactedSlave) Read(p []byte) (n int, err error) {
+	return slave.output.Read(p)
+}
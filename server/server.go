@@ -5,12 +5,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"embed"
-	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	noesctmpl "text/template"
 	"time"
 
@@ -20,6 +21,9 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/labbs/webtty/pkg/homedir"
+	"github.com/labbs/webtty/pkg/policy"
+	"github.com/labbs/webtty/pkg/token"
+	"github.com/labbs/webtty/pkg/users"
 	"github.com/labbs/webtty/webtty"
 )
 
@@ -29,6 +33,9 @@ var assets embed.FS
 //go:embed templates/index.html
 var indexTemplate string
 
+//go:embed templates/admin.html
+var adminTemplate string
+
 // Server provides a webtty HTTP endpoint.
 type Server struct {
 	factory Factory
@@ -36,11 +43,37 @@ type Server struct {
 
 	upgrader      *websocket.Upgrader
 	titleTemplate *noesctmpl.Template
+	sessions      *sessionBroker
+	metrics       *serverMetrics
+	// certificate is the TLS certificate served if TLS is enabled
+	certificate *certificate
+	// randomPath is the secret path segment if random URLs are enabled
+	randomPath *randomPath
+	listeners  []net.Listener
+	// signer signs cookies and tokens handed to clients
+	signer *token.Signer
+	// oidc authenticates users if OpenID Connect is enabled
+	oidc     *oidcAuth
+	wsTokens *wsTokens
+	// users are authenticated with basic auth and given roles if the users file is given
+	users *users.File
+	// policy restricts what clients request if the policy file is given
+	policy *policy.Policy
+	// shareLinks grant access to sessions if the admin API is enabled
+	shareLinks *shareLinks
+	// non-zero after the gracefull context is done
+	draining int32
+	// number of listeners serving requests
+	listening int32
 }
 
 // New creates a new instance of Server.
 // Server will use the New() of the factory provided to handle each request.
 func New(factory Factory, options *Options) (*Server, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
 	titleTemplate, err := noesctmpl.New("title").Parse(options.TitleFormat)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse window title format `%s`", options.TitleFormat)
@@ -57,6 +90,68 @@ func New(factory Factory, options *Options) (*Server, error) {
 		}
 	}
 
+	var cert *certificate
+	if options.EnableTLS {
+		crtFile := homedir.Expand(options.TLSCrtFile)
+		keyFile := homedir.Expand(options.TLSKeyFile)
+		if options.TLSSelfSigned {
+			hosts := []string{}
+			if host := options.Address; host != "" && host != "systemd" && !strings.HasPrefix(host, "unix:") {
+				hosts = append(hosts, host)
+			}
+			if _, err := generateSelfSignedCertificate(crtFile, keyFile, hosts); err != nil {
+				return nil, errors.Wrapf(err, "failed to generate a self-signed TLS certificate")
+			}
+		}
+		cert, err = newCertificate(crtFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var rp *randomPath
+	if options.EnableRandomUrl {
+		rp = newRandomPath(options.RandomUrlLength)
+	}
+
+	signer, err := token.NewSigner([]byte(options.SecretKey))
+	if err != nil {
+		return nil, err
+	}
+	var usersFile *users.File
+	if options.UsersFile != "" {
+		usersFile, err = users.Load(homedir.Expand(options.UsersFile))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var pol *policy.Policy
+	if options.PolicyFile != "" {
+		pol, err = policy.Load(homedir.Expand(options.PolicyFile))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var oidcAuth *oidcAuth
+	if options.OIDCIssuer != "" {
+		cookiePath := options.Path
+		if !strings.HasSuffix(cookiePath, "/") {
+			cookiePath = cookiePath + "/"
+		}
+		oidcAuth, err = newOIDCAuth(options, cookiePath, signer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	metrics := newServerMetrics()
+	sessions := newSessionBroker(options, metrics)
+	metrics.registry.NewGaugeFunc("gotty_sessions_active", "Number of active sessions.", func() float64 {
+		return float64(sessions.count())
+	})
+
 	return &Server{
 		factory: factory,
 		options: options,
@@ -68,9 +163,32 @@ func New(factory Factory, options *Options) (*Server, error) {
 			CheckOrigin:     originChekcer,
 		},
 		titleTemplate: titleTemplate,
+		sessions:      sessions,
+		metrics:       metrics,
+		certificate:   cert,
+		randomPath:    rp,
+		signer:        signer,
+		oidc:          oidcAuth,
+		wsTokens:      newWSTokens(signer.Derive("websocket")),
+		users:         usersFile,
+		policy:        pol,
+		shareLinks:    newShareLinks(signer.Derive("share")),
 	}, nil
 }
 
+// ReloadCertificate loads the TLS certificate files again for new connections.
+// Established connections are not affected.
+func (server *Server) ReloadCertificate() error {
+	if server.certificate == nil {
+		return errors.New("TLS is not enabled")
+	}
+	if err := server.certificate.reload(); err != nil {
+		return err
+	}
+	log.Printf("Reloaded TLS certificate: %s", server.certificate.crtFile)
+	return nil
+}
+
 // Run starts the main process of the Server.
 // The cancelation of ctx will shutdown the server immediately with aborting
 // existing connections. Use WithGracefullContext() to support gracefull shutdown.
@@ -82,6 +200,7 @@ func (server *Server) Run(ctx context.Context, options ...RunOption) error {
 	}
 
 	counter := newCounter(time.Duration(server.options.Timeout) * time.Second)
+	server.metrics.setCounter(counter)
 
 	handlers := server.setupHandlers(cctx, cancel, server.options.Path, counter)
 	srv, err := server.setupHTTPServer(handlers)
@@ -89,7 +208,9 @@ func (server *Server) Run(ctx context.Context, options ...RunOption) error {
 		return errors.Wrapf(err, "failed to setup an HTTP server")
 	}
 
-	if server.options.PermitWrite {
+	if server.users != nil {
+		log.Printf("Permitting operators in the users file to write input to the PTY.")
+	} else if server.options.PermitWrite {
 		log.Printf("Permitting clients to write input to the PTY.")
 	}
 	if server.options.Once {
@@ -101,27 +222,64 @@ func (server *Server) Run(ctx context.Context, options ...RunOption) error {
 	}
 
 	srvErr := make(chan error, 1)
-	go func() {
-		if server.options.EnableTLS {
-			crtFile := homedir.Expand(server.options.TLSCrtFile)
-			keyFile := homedir.Expand(server.options.TLSKeyFile)
-			log.Printf("TLS crt file: " + crtFile)
-			log.Printf("TLS key file: " + keyFile)
-
-			// err = srv.ServeTLS(listener, crtFile, keyFile)
-			err = http.ListenAndServeTLS(fmt.Sprintf("%s:%s", server.options.Address, server.options.Port), crtFile, keyFile, handlers)
-		} else {
-			// err = srv.Serve(listener)
-			err = http.ListenAndServe(fmt.Sprintf("%s:%s", server.options.Address, server.options.Port), handlers)
-		}
-		if err != nil {
-			srvErr <- err
+
+	if server.options.EnableMetrics && server.options.MetricsAddress != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", server.metrics.registry.Handler())
+		metricsSrv := &http.Server{Addr: server.options.MetricsAddress, Handler: metricsMux}
+		log.Printf("Serving metrics at http://%s/metrics", server.options.MetricsAddress)
+		go func() {
+			err := metricsSrv.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				srvErr <- errors.Wrapf(err, "failed to serve metrics")
+			}
+		}()
+		defer metricsSrv.Close()
+	}
+
+	listeners, err := server.listen()
+	if err != nil {
+		return err
+	}
+
+	if server.options.EnableTLS {
+		log.Printf("TLS crt file: " + server.certificate.crtFile)
+		log.Printf("TLS key file: " + server.certificate.keyFile)
+		if server.options.TLSReload {
+			go server.certificate.watch(certificateCheckInterval, cctx.Done())
 		}
-	}()
+	}
+
+	server.listeners = listeners
+	for _, listener := range listeners {
+		log.Printf("Listening on %s:%s", listener.Addr().Network(), listener.Addr())
+	}
+	server.logURLs()
+
+	atomic.StoreInt32(&server.listening, int32(len(listeners)))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			defer atomic.AddInt32(&server.listening, -1)
+			var err error
+			if server.options.EnableTLS {
+				// the certificate is served by TLSConfig.GetCertificate
+				err = srv.ServeTLS(listener, "", "")
+			} else {
+				err = srv.Serve(listener)
+			}
+			if err != nil {
+				select {
+				case srvErr <- err:
+				default:
+				}
+			}
+		}(listener)
+	}
 
 	go func() {
 		select {
 		case <-opts.gracefullCtx.Done():
+			atomic.StoreInt32(&server.draining, 1)
 			srv.Shutdown(context.Background())
 		case <-cctx.Done():
 		}
@@ -144,6 +302,7 @@ func (server *Server) Run(ctx context.Context, options ...RunOption) error {
 		log.Printf("Waiting for %d connections to be closed", conn)
 	}
 	counter.wait()
+	server.sessions.closeAll()
 
 	return err
 }
@@ -158,13 +317,46 @@ func (server *Server) setupHandlers(ctx context.Context, cancel context.CancelFu
 		path = path + "/"
 	}
 
-	siteMux.Handle(path+"static/", http.StripPrefix(path, http.FileServer(staticFS)))
+	staticHandler := http.StripPrefix(path, http.FileServer(staticFS))
+	siteMux.Handle(path+"static/", staticHandler)
+	siteMux.HandleFunc(path+"token", server.handleWSToken)
+	if server.options.EnableMetrics && server.options.MetricsAddress == "" {
+		siteMux.Handle(path+"metrics", server.metrics.registry.Handler())
+	}
 
 	siteHandler := http.Handler(siteMux)
+	wsHandler := http.Handler(server.generateHandleWS(ctx, cancel, counter, false))
+	publicWSHandler := wsHandler
 
 	if server.options.EnableBasicAuth {
 		log.Printf("Using Basic Authentication")
-		siteHandler = server.wrapBasicAuth(siteHandler, server.options.Credential)
+		check := credentialChecker(server.options.Credential)
+		if server.users != nil {
+			check = usersChecker(server.users)
+		}
+		siteHandler = server.wrapBasicAuth(siteHandler, check, "GoTTY")
+	}
+	if server.oidc != nil {
+		log.Printf("Using OpenID Connect issued by %s", server.options.OIDCIssuer)
+		siteHandler = server.wrapOIDC(siteHandler, path+"oidc/login")
+		wsHandler = server.wrapOIDC(wsHandler, path+"oidc/login")
+	}
+	if server.options.AdminCredential != "" {
+		// share links minted with the admin API stand in for the authentication
+		// of the page, its assets and tokens, and of the websocket connections,
+		// which are only accepted with tokens minted with the same links
+		shareMux := http.NewServeMux()
+		shareMux.HandleFunc(pathPrefix, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != pathPrefix && r.URL.Path != path {
+				http.NotFound(w, r)
+				return
+			}
+			server.handleIndex(w, r)
+		})
+		shareMux.Handle(path+"static/", staticHandler)
+		shareMux.HandleFunc(path+"token", server.handleWSToken)
+		siteHandler = server.wrapShareLink(siteHandler, shareMux)
+		wsHandler = server.wrapShareLink(wsHandler, publicWSHandler)
 	}
 
 	withGz := gziphandler.GzipHandler(server.wrapHeaders(siteHandler))
@@ -172,22 +364,87 @@ func (server *Server) setupHandlers(ctx context.Context, cancel context.CancelFu
 
 	wsMux := http.NewServeMux()
 	wsMux.Handle("/", siteHandler)
-	wsMux.HandleFunc(path+"ws", server.generateHandleWS(ctx, cancel, counter))
+	wsMux.Handle(path+"ws", wsHandler)
+	if server.oidc != nil {
+		oidcMux := http.NewServeMux()
+		oidcMux.Handle(path+"oidc/login", server.handleOIDCLogin(path+"oidc/callback"))
+		oidcMux.Handle(path+"oidc/callback", server.handleOIDCCallback())
+		oidcMux.HandleFunc(path+"oidc/logout", server.handleOIDCLogout)
+		wsMux.Handle(path+"oidc/", server.wrapLogger(server.wrapHeaders(oidcMux)))
+	}
+	if server.options.AdminCredential != "" {
+		log.Printf("Serving the admin dashboard at %sadmin/", path)
+		server.setupAdminHandlers(ctx, cancel, path, counter, wsMux)
+	}
+	// probes of orchestrators are neither authenticated nor logged
+	wsMux.HandleFunc(path+"healthz", server.handleHealthz)
+	wsMux.HandleFunc(path+"readyz", server.generateHandleReadyz(counter))
 	siteHandler = http.Handler(wsMux)
 
+	if server.randomPath != nil {
+		siteHandler = server.wrapRandomPath(siteHandler, pathPrefix)
+	}
+	if len(server.options.TLSClientAllowedCNs) > 0 || len(server.options.TLSClientAllowedOUs) > 0 {
+		log.Printf("Restricting TLS clients to allowed certificates")
+		siteHandler = server.wrapClientCertAuth(siteHandler, server.options.TLSClientAllowedCNs, server.options.TLSClientAllowedOUs)
+	}
+
 	return siteHandler
 }
 
+// setupAdminHandlers adds the handlers for administrators to mux,
+// which are authenticated with the admin credential.
+//
+//	{path}api/sessions        the admin API
+//	{path}api/links           the admin API of share links
+//	{path}admin/              the dashboard
+//	{path}admin/api/sessions  the admin API for the dashboard
+//	{path}admin/shadow/       the terminal to watch a session in the shadow mode
+//	{path}admin/static/       assets of the pages
+func (server *Server) setupAdminHandlers(ctx context.Context, cancel context.CancelFunc, path string, counter *counter, mux *http.ServeMux) {
+	adminPath := path + "admin/"
+
+	adminMux := http.NewServeMux()
+	adminMux.Handle(path+"api/sessions", server.handleAdminSessions(path+"api/sessions", path))
+	adminMux.Handle(path+"api/sessions/", server.handleAdminSessions(path+"api/sessions", path))
+	adminMux.Handle(path+"api/links", server.handleAdminShareLinks(path+"api/links"))
+	adminMux.Handle(path+"api/links/", server.handleAdminShareLinks(path+"api/links"))
+	adminMux.Handle(adminPath+"api/sessions", server.handleAdminSessions(adminPath+"api/sessions", path))
+	adminMux.Handle(adminPath+"api/sessions/", server.handleAdminSessions(adminPath+"api/sessions", path))
+	adminMux.Handle(adminPath, server.handleAdminDashboard(adminPath))
+	adminMux.HandleFunc(adminPath+"shadow/", func(w http.ResponseWriter, r *http.Request) {
+		server.renderIndex(w, r, adminPath)
+	})
+	adminMux.Handle(adminPath+"static/", http.StripPrefix(adminPath, http.FileServer(http.FS(assets))))
+
+	handler := server.wrapBasicAuth(adminMux, credentialChecker(server.options.AdminCredential), "GoTTY Admin")
+	handler = server.wrapLogger(gziphandler.GzipHandler(server.wrapHeaders(handler)))
+
+	wsHandler := server.wrapBasicAuth(server.generateHandleWS(ctx, cancel, counter, true), credentialChecker(server.options.AdminCredential), "GoTTY Admin")
+
+	mux.Handle(path+"api/sessions", handler)
+	mux.Handle(path+"api/sessions/", handler)
+	mux.Handle(path+"api/links", handler)
+	mux.Handle(path+"api/links/", handler)
+	mux.Handle(adminPath, handler)
+	mux.Handle(adminPath+"shadow/ws", wsHandler)
+}
+
 func (server *Server) setupHTTPServer(handler http.Handler) (*http.Server, error) {
 	srv := &http.Server{
 		Handler: handler,
 	}
 
+	if server.certificate != nil {
+		srv.TLSConfig = &tls.Config{GetCertificate: server.certificate.GetCertificate}
+	}
+
 	if server.options.EnableTLSClientAuth {
 		tlsConfig, err := server.tlsConfig()
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to setup TLS configuration")
 		}
+		tlsConfig.GetCertificate = srv.TLSConfig.GetCertificate
 		srv.TLSConfig = tlsConfig
 	}
 
@@ -0,0 +1,696 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/audit"
+	"github.com/labbs/webtty/backend/cmdline"
+	"github.com/labbs/webtty/pkg/randomstring"
+	"github.com/labbs/webtty/webtty"
+)
+
+const (
+	sessionIDLength = 20
+	tokenLength     = 32
+	// number of slave reads buffered for each viewer
+	viewerBufferSize = 256
+)
+
+var (
+	errSessionClosed  = errors.New("session closed")
+	errSlowViewer     = errors.New("viewer could not keep up with the output")
+	errViewerReplaced = errors.New("viewer replaced by a resumed connection")
+	errInvalidToken   = errors.New("invalid session token")
+)
+
+// sessionBroker keeps track of sessions so that
+// masters can attach to an existing slave by its session ID.
+type sessionBroker struct {
+	options *Options
+	metrics *serverMetrics
+	// duration to keep sessions alive without viewers
+	gracePeriod time.Duration
+	// duration to keep resume tokens of detached viewers valid
+	tokenLifetime time.Duration
+	// number of bytes of output replayed to viewers attaching to a session
+	scrollbackSize int
+
+	sessions map[string]*session
+	mutex    sync.Mutex
+}
+
+// defaultTokenLifetime is the lifetime of resume tokens of detached viewers
+// without the grace period, as resuming sessions other viewers keep alive.
+const defaultTokenLifetime = time.Minute
+
+func newSessionBroker(options *Options, metrics *serverMetrics) *sessionBroker {
+	broker := &sessionBroker{
+		options:        options,
+		metrics:        metrics,
+		gracePeriod:    time.Duration(options.SessionGracePeriod) * time.Second,
+		tokenLifetime:  defaultTokenLifetime,
+		scrollbackSize: options.ScrollbackSize * 1024,
+		sessions:       map[string]*session{},
+	}
+	if broker.gracePeriod > 0 {
+		broker.tokenLifetime = broker.gracePeriod
+	}
+	return broker
+}
+
+// create starts a new session for the slave created for a client at remoteAddr
+// authenticated as user.
+func (broker *sessionBroker) create(slave Slave, remoteAddr string, user string) (*session, error) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	id := randomstring.Generate(sessionIDLength)
+	for broker.sessions[id] != nil {
+		id = randomstring.Generate(sessionIDLength)
+	}
+	startTime := time.Now()
+	vars := slave.WindowTitleVariables()
+	waiter, waitable := slave.(exitWaiter)
+
+	if broker.options.Recorder != nil {
+		recorded, err := newRecordedSlave(slave, broker.options.Recorder, id, remoteAddr, startTime, broker.options)
+		if err != nil {
+			return nil, err
+		}
+		slave = recorded
+	}
+	// recordings are redacted independently by the recorder
+	if broker.options.Redactor != nil {
+		slave = newRedactedSlave(slave, broker.options.Redactor)
+	}
+
+	sess := newSession(id, slave, broker)
+	sess.remoteAddr = remoteAddr
+	sess.user = user
+	sess.startTime = startTime
+	if command, ok := vars["command"]; ok {
+		sess.command = fmt.Sprint(command)
+	}
+	sess.argv, _ = vars["argv"].([]string)
+	sess.pid, _ = vars["pid"].(int)
+	broker.sessions[id] = sess
+	broker.metrics.sessionsCreated.Inc()
+
+	auditEvent(broker.options.Auditor, audit.Event{
+		Time:       startTime,
+		Type:       audit.EventSessionStart,
+		SessionID:  id,
+		User:       user,
+		RemoteAddr: remoteAddr,
+		Command:    sess.command,
+		Argv:       sess.argv,
+		PID:        sess.pid,
+	})
+
+	if waitable && broker.options.Auditor != nil {
+		go func() {
+			code := waiter.WaitExit()
+			auditEvent(broker.options.Auditor, audit.Event{
+				Type:      audit.EventExit,
+				SessionID: id,
+				User:      user,
+				PID:       sess.pid,
+				ExitCode:  &code,
+			})
+		}()
+	}
+
+	return sess, nil
+}
+
+func (broker *sessionBroker) get(id string) (*session, bool) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	sess, ok := broker.sessions[id]
+	return sess, ok
+}
+
+// resume finds the session issued the token to one of its viewers.
+func (broker *sessionBroker) resume(token string) (*session, bool) {
+	id := strings.SplitN(token, ".", 2)[0]
+	sess, ok := broker.get(id)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := sess.lookupToken(token); !ok {
+		return nil, false
+	}
+
+	return sess, true
+}
+
+// list returns the sessions in the order of their start times.
+func (broker *sessionBroker) list() []*session {
+	broker.mutex.Lock()
+	sessions := make([]*session, 0, len(broker.sessions))
+	for _, sess := range broker.sessions {
+		sessions = append(sessions, sess)
+	}
+	broker.mutex.Unlock()
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].startTime.Before(sessions[j].startTime)
+	})
+	return sessions
+}
+
+func (broker *sessionBroker) count() int {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	return len(broker.sessions)
+}
+
+func (broker *sessionBroker) remove(id string) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	delete(broker.sessions, id)
+}
+
+// closeAll closes all the sessions including detached ones.
+func (broker *sessionBroker) closeAll() {
+	broker.mutex.Lock()
+	sessions := make([]*session, 0, len(broker.sessions))
+	for _, sess := range broker.sessions {
+		sessions = append(sessions, sess)
+	}
+	broker.mutex.Unlock()
+
+	for _, sess := range sessions {
+		sess.close()
+	}
+}
+
+// session is a slave shared by one or more viewers.
+// Output of the slave is fanned out to all the viewers and
+// input from the viewers permitted to write is merged into the slave.
+type session struct {
+	id     string
+	slave  Slave
+	broker *sessionBroker
+
+	// address and user of the client created the session
+	remoteAddr string
+	user       string
+	startTime  time.Time
+	command    string
+	argv       []string
+	pid        int
+	// bytes transferred, accessed atomically
+	bytesIn  int64
+	bytesOut int64
+
+	viewers map[*viewer]struct{}
+	// resume tokens issued to the viewers, mapped to what they grant
+	tokens     map[string]resumeGrant
+	scrollback *webtty.Scrollback
+	graceTimer *time.Timer
+	exited     bool
+	closed     bool
+	mutex      sync.Mutex
+	writeMutex sync.Mutex
+}
+
+func newSession(id string, slave Slave, broker *sessionBroker) *session {
+	sess := &session{
+		id:     id,
+		slave:  slave,
+		broker: broker,
+
+		viewers:    map[*viewer]struct{}{},
+		tokens:     map[string]resumeGrant{},
+		scrollback: webtty.NewScrollback(broker.scrollbackSize),
+	}
+	go sess.pump()
+
+	return sess
+}
+
+// resumeGrant is what a resume token grants to the client taking over the viewer.
+type resumeGrant struct {
+	readOnly bool
+	// identity is the one authenticated when the token was issued,
+	// as clients resuming sessions may not authenticate again
+	identity clientIdentity
+	// expires is set when the viewer is detached
+	expires time.Time
+}
+
+func (grant resumeGrant) expired(now time.Time) bool {
+	return !grant.expires.IsZero() && now.After(grant.expires)
+}
+
+// lookupToken returns what the token issued to a viewer grants.
+func (sess *session) lookupToken(token string) (resumeGrant, bool) {
+	sess.mutex.Lock()
+	defer sess.mutex.Unlock()
+
+	grant, ok := sess.tokens[token]
+	if !ok || grant.expired(time.Now()) {
+		return resumeGrant{}, false
+	}
+	return grant, true
+}
+
+// purgeTokens removes the expired tokens.
+// The caller must hold the mutex of the session.
+func (sess *session) purgeTokens() {
+	now := time.Now()
+	for token, grant := range sess.tokens {
+		if grant.expired(now) {
+			delete(sess.tokens, token)
+		}
+	}
+}
+
+// pump reads the slave and distributes its output to the viewers.
+func (sess *session) pump() {
+	buffer := make([]byte, 1024)
+	for {
+		n, err := sess.slave.Read(buffer)
+		if err != nil {
+			break
+		}
+
+		data := make([]byte, n)
+		copy(data, buffer[:n])
+		sess.broker.metrics.bytes.Add("out", float64(n))
+		atomic.AddInt64(&sess.bytesOut, int64(n))
+
+		sess.mutex.Lock()
+		sess.scrollback.Write(data)
+		for viewer := range sess.viewers {
+			select {
+			case viewer.output <- data:
+			default:
+				// a slow viewer must not block the others
+				delete(sess.viewers, viewer)
+				viewer.err = errSlowViewer
+				close(viewer.output)
+			}
+		}
+		sess.mutex.Unlock()
+	}
+
+	sess.mutex.Lock()
+	sess.exited = true
+	// the first viewer may not have attached yet
+	detached := sess.countViewers() == 0 && sess.graceTimer != nil
+	for viewer := range sess.viewers {
+		close(viewer.output)
+	}
+	sess.viewers = map[*viewer]struct{}{}
+	sess.mutex.Unlock()
+
+	// nobody is going to detach from the session anymore
+	if detached {
+		sess.close()
+	}
+}
+
+// attach adds the viewer to the session.
+// When resumeToken is given, the viewer takes over the viewer the token was issued to.
+// Output kept in the scrollback is replayed to the viewer before live output.
+func (sess *session) attach(viewer *viewer, resumeToken string) error {
+	sess.mutex.Lock()
+	if sess.closed {
+		sess.mutex.Unlock()
+		return errSessionClosed
+	}
+	sess.purgeTokens()
+	if resumeToken != "" {
+		if _, ok := sess.tokens[resumeToken]; !ok {
+			sess.mutex.Unlock()
+			return errInvalidToken
+		}
+		// tokens are redeemed only once
+		delete(sess.tokens, resumeToken)
+		for other := range sess.viewers {
+			if other.token == resumeToken {
+				delete(sess.viewers, other)
+				other.err = errViewerReplaced
+				close(other.output)
+			}
+		}
+	}
+	// shadow viewers do not keep the session alive
+	if sess.graceTimer != nil && !viewer.shadow {
+		sess.graceTimer.Stop()
+		sess.graceTimer = nil
+	}
+	if replay := sess.scrollback.Replay(); replay != nil {
+		viewer.output <- replay
+	}
+	if !viewer.shadow {
+		sess.tokens[viewer.token] = resumeGrant{readOnly: viewer.readOnly, identity: viewer.identity}
+	}
+	if sess.exited {
+		// the viewer only receives the last output
+		close(viewer.output)
+	} else {
+		sess.viewers[viewer] = struct{}{}
+	}
+	num := sess.countViewers()
+	sess.mutex.Unlock()
+
+	if viewer.shadow {
+		log.Printf("Client %s started shadowing session %s", viewer.remoteAddr, sess.id)
+		return nil
+	}
+
+	log.Printf("Client %s attached to session %s, viewers: %d", viewer.remoteAddr, sess.id, num)
+	sess.broadcast(viewer, sessionEvent{
+		Type:       "join",
+		RemoteAddr: viewer.remoteAddr,
+		ReadOnly:   viewer.readOnly,
+		Viewers:    num,
+	})
+
+	return nil
+}
+
+// detach removes the viewer from the session.
+// The session is closed when its last viewer leaves,
+// after the grace period of the broker if any.
+func (sess *session) detach(viewer *viewer) {
+	sess.mutex.Lock()
+	if _, ok := sess.viewers[viewer]; ok {
+		delete(sess.viewers, viewer)
+		close(viewer.output)
+	}
+	if viewer.shadow {
+		sess.mutex.Unlock()
+		log.Printf("Client %s stopped shadowing session %s", viewer.remoteAddr, sess.id)
+		return
+	}
+	// the token is kept for a while for the client to resume the session
+	if grant, ok := sess.tokens[viewer.token]; ok {
+		grant.expires = time.Now().Add(sess.broker.tokenLifetime)
+		sess.tokens[viewer.token] = grant
+	}
+	sess.purgeTokens()
+	num := sess.countViewers()
+	keep := num == 0 && !sess.exited && !sess.closed && sess.broker.gracePeriod > 0
+	if keep && sess.graceTimer == nil {
+		sess.graceTimer = time.AfterFunc(sess.broker.gracePeriod, sess.expire)
+	}
+	sess.mutex.Unlock()
+
+	log.Printf("Client %s detached from session %s, viewers: %d", viewer.remoteAddr, sess.id, num)
+
+	if num == 0 {
+		if keep {
+			log.Printf("Keeping session %s for %s", sess.id, sess.broker.gracePeriod)
+		} else {
+			sess.close()
+		}
+		return
+	}
+
+	sess.broadcast(viewer, sessionEvent{
+		Type:       "leave",
+		RemoteAddr: viewer.remoteAddr,
+		ReadOnly:   viewer.readOnly,
+		Viewers:    num,
+	})
+	sess.resize()
+}
+
+// expire closes the session unless a viewer has resumed it during the grace period.
+func (sess *session) expire() {
+	sess.mutex.Lock()
+	expired := sess.countViewers() == 0
+	sess.mutex.Unlock()
+
+	if expired {
+		log.Printf("Grace period of session %s expired", sess.id)
+		sess.close()
+	}
+}
+
+// countViewers returns the number of viewers except shadow ones.
+// The caller must hold the mutex of the session.
+func (sess *session) countViewers() int {
+	num := 0
+	for viewer := range sess.viewers {
+		if !viewer.shadow {
+			num++
+		}
+	}
+	return num
+}
+
+func (sess *session) close() {
+	sess.mutex.Lock()
+	if sess.closed {
+		sess.mutex.Unlock()
+		return
+	}
+	sess.closed = true
+	if sess.graceTimer != nil {
+		sess.graceTimer.Stop()
+		sess.graceTimer = nil
+	}
+	sess.mutex.Unlock()
+
+	sess.broker.remove(sess.id)
+	sess.slave.Close()
+	sess.broker.metrics.slaveLifetime.Observe(time.Since(sess.startTime).Seconds())
+}
+
+// broadcast notifies the viewers except the origin of the event.
+func (sess *session) broadcast(origin *viewer, event sessionEvent) {
+	sess.mutex.Lock()
+	viewers := make([]*viewer, 0, len(sess.viewers))
+	for viewer := range sess.viewers {
+		if viewer != origin && !viewer.shadow {
+			viewers = append(viewers, viewer)
+		}
+	}
+	sess.mutex.Unlock()
+
+	for _, viewer := range viewers {
+		if viewer.notify != nil {
+			viewer.notify(event)
+		}
+	}
+}
+
+// resize sets the slave to the smallest size requested by the viewers,
+// so that the whole terminal is visible for all of them.
+func (sess *session) resize() error {
+	sess.mutex.Lock()
+	columns, rows := 0, 0
+	for viewer := range sess.viewers {
+		if viewer.shadow {
+			continue
+		}
+		if viewer.columns > 0 && (columns == 0 || viewer.columns < columns) {
+			columns = viewer.columns
+		}
+		if viewer.rows > 0 && (rows == 0 || viewer.rows < rows) {
+			rows = viewer.rows
+		}
+	}
+	sess.mutex.Unlock()
+
+	if columns == 0 || rows == 0 {
+		return nil
+	}
+	return sess.slave.ResizeTerminal(columns, rows)
+}
+
+// write sends input of the viewer to the slave.
+// Command lines submitted by the input are audited when enabled.
+func (sess *session) write(viewer *viewer, p []byte) (n int, err error) {
+	sess.writeMutex.Lock()
+	defer sess.writeMutex.Unlock()
+
+	if viewer.assembler != nil {
+		for _, line := range viewer.assembler.Push(p) {
+			// command lines can contain secrets like live output
+			if redactor := sess.broker.options.Redactor; redactor != nil {
+				line = string(redactor.Redact([]byte(line)))
+			}
+			auditEvent(sess.broker.options.Auditor, audit.Event{
+				Type:       audit.EventCommand,
+				SessionID:  sess.id,
+				User:       viewer.user,
+				RemoteAddr: viewer.remoteAddr,
+				Command:    line,
+			})
+		}
+	}
+
+	n, err = sess.slave.Write(p)
+	sess.broker.metrics.bytes.Add("in", float64(n))
+	atomic.AddInt64(&sess.bytesIn, int64(n))
+	return n, err
+}
+
+// sessionInfo describes a session for administrators.
+type sessionInfo struct {
+	ID         string    `json:"id"`
+	RemoteAddr string    `json:"remote_addr"`
+	User       string    `json:"user,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	Command    string    `json:"command"`
+	Argv       []string  `json:"argv"`
+	PID        int       `json:"pid,omitempty"`
+	Viewers    int       `json:"viewers"`
+	Shadows    int       `json:"shadows"`
+	BytesIn    int64     `json:"bytes_in"`
+	BytesOut   int64     `json:"bytes_out"`
+}
+
+func (sess *session) info() sessionInfo {
+	sess.mutex.Lock()
+	viewers := sess.countViewers()
+	shadows := len(sess.viewers) - viewers
+	sess.mutex.Unlock()
+
+	return sessionInfo{
+		ID:         sess.id,
+		RemoteAddr: sess.remoteAddr,
+		User:       sess.user,
+		StartTime:  sess.startTime,
+		Command:    sess.command,
+		Argv:       sess.argv,
+		PID:        sess.pid,
+		Viewers:    viewers,
+		Shadows:    shadows,
+		BytesIn:    atomic.LoadInt64(&sess.bytesIn),
+		BytesOut:   atomic.LoadInt64(&sess.bytesOut),
+	}
+}
+
+type sessionEvent struct {
+	Type       string
+	RemoteAddr string
+	ReadOnly   bool
+	Viewers    int
+}
+
+// viewer is a webtty.Slave representing a master attached to a session.
+type viewer struct {
+	session    *session
+	token      string
+	remoteAddr string
+	user       string
+	identity   clientIdentity
+	readOnly   bool
+	// shadow viewers are administrators watching the session unnoticed
+	shadow bool
+	notify func(event interface{}) error
+
+	output  chan []byte
+	pending []byte
+	err     error
+
+	columns int
+	rows    int
+
+	denied sync.Once
+	// assembler reconstructs command lines input by the viewer for auditing, if enabled.
+	// Lines are attributed to the viewers typing them, so a line several viewers
+	// of the session type into together is audited as the part each of them typed.
+	assembler *cmdline.Assembler
+}
+
+// newViewer creates a viewer of the session.
+// The viewer starts receiving output after attached.
+func (sess *session) newViewer(remoteAddr string, id clientIdentity, readOnly bool) *viewer {
+	viewer := &viewer{
+		session:    sess,
+		token:      sess.id + "." + randomstring.Generate(tokenLength),
+		remoteAddr: remoteAddr,
+		user:       id.User,
+		identity:   id,
+		readOnly:   readOnly,
+
+		output: make(chan []byte, viewerBufferSize),
+		err:    io.EOF,
+	}
+	if sess.broker.options.Auditor != nil {
+		viewer.assembler = cmdline.NewAssembler()
+	}
+	return viewer
+}
+
+func (viewer *viewer) Read(p []byte) (n int, err error) {
+	if len(viewer.pending) == 0 {
+		data, ok := <-viewer.output
+		if !ok {
+			return 0, viewer.err
+		}
+		viewer.pending = data
+	}
+
+	n = copy(p, viewer.pending)
+	viewer.pending = viewer.pending[n:]
+
+	return n, nil
+}
+
+func (viewer *viewer) Write(p []byte) (n int, err error) {
+	if viewer.readOnly {
+		viewer.denyWrite()
+		return len(p), nil
+	}
+
+	return viewer.session.write(viewer, p)
+}
+
+// denyWrite audits input discarded as the viewer is read-only, once for each viewer.
+func (viewer *viewer) denyWrite() {
+	viewer.denied.Do(func() {
+		auditEvent(viewer.session.broker.options.Auditor, audit.Event{
+			Type:       audit.EventWriteDenied,
+			SessionID:  viewer.session.id,
+			User:       viewer.user,
+			RemoteAddr: viewer.remoteAddr,
+		})
+	})
+}
+
+func (viewer *viewer) WindowTitleVariables() map[string]interface{} {
+	return viewer.session.slave.WindowTitleVariables()
+}
+
+func (viewer *viewer) ResizeTerminal(columns int, rows int) error {
+	if viewer.shadow {
+		return nil
+	}
+
+	viewer.session.mutex.Lock()
+	viewer.columns = columns
+	viewer.rows = rows
+	viewer.session.mutex.Unlock()
+
+	auditEvent(viewer.session.broker.options.Auditor, audit.Event{
+		Type:       audit.EventResize,
+		SessionID:  viewer.session.id,
+		User:       viewer.user,
+		RemoteAddr: viewer.remoteAddr,
+		Columns:    columns,
+		Rows:       rows,
+	})
+
+	return viewer.session.resize()
+}
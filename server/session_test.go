@@ -0,0 +1,64 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionResumeTokens(t *testing.T) {
+	broker := newSessionBroker(&Options{}, newServerMetrics())
+	slave := &testSlave{input: make(chan []byte, 1), done: make(chan struct{})}
+	sess, err := broker.create(slave, "127.0.0.1", "alice")
+	if err != nil {
+		t.Fatalf("create() = %v", err)
+	}
+	defer sess.close()
+
+	attach := func(resumeToken string) *viewer {
+		t.Helper()
+		viewer := sess.newViewer("127.0.0.1", clientIdentity{User: "alice"}, false)
+		if err := sess.attach(viewer, resumeToken); err != nil {
+			t.Fatalf("attach() = %v", err)
+		}
+		return viewer
+	}
+	tokens := func() int {
+		sess.mutex.Lock()
+		defer sess.mutex.Unlock()
+		return len(sess.tokens)
+	}
+
+	// another viewer keeps the session alive
+	attach("")
+	first := attach("")
+
+	// tokens of detached viewers are valid for a while
+	sess.detach(first)
+	if grant, ok := sess.lookupToken(first.token); !ok || grant.identity.User != "alice" {
+		t.Fatalf("lookupToken() = %+v, %t after detached; want grant of alice", grant, ok)
+	}
+
+	// and redeemed only once
+	second := attach(first.token)
+	if _, ok := sess.lookupToken(first.token); ok {
+		t.Errorf("lookupToken() succeeded for token redeemed")
+	}
+	if err := sess.attach(sess.newViewer("127.0.0.1", clientIdentity{}, false), first.token); err != errInvalidToken {
+		t.Errorf("attach() with token redeemed = %v, want %v", err, errInvalidToken)
+	}
+	if n := tokens(); n != 2 {
+		t.Fatalf("%d tokens kept, want 2 of the viewers attached", n)
+	}
+
+	// tokens of viewers leaving for good expire
+	broker.tokenLifetime = time.Millisecond
+	sess.detach(second)
+	time.Sleep(2 * time.Millisecond)
+	if _, ok := sess.lookupToken(second.token); ok {
+		t.Errorf("lookupToken() succeeded for token expired")
+	}
+	attach("")
+	if n := tokens(); n != 2 {
+		t.Errorf("%d tokens kept, want 2 without the one expired", n)
+	}
+}
@@ -0,0 +1,364 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/audit"
+	"github.com/labbs/webtty/pkg/token"
+)
+
+// Modes of share links
+const (
+	shareModeReadOnly  = "read-only"
+	shareModeReadWrite = "read-write"
+)
+
+// shareLink grants access to a session for a limited time.
+type shareLink struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Mode      string    `json:"mode"`
+	Expires   time.Time `json:"expires"`
+	// MaxUses limits connections with the link, unlimited if zero
+	MaxUses   int    `json:"max_uses,omitempty"`
+	Uses      int    `json:"uses"`
+	Revoked   bool   `json:"revoked"`
+	CreatedBy string `json:"created_by,omitempty"`
+}
+
+func (link *shareLink) readOnly() bool {
+	return link.Mode != shareModeReadWrite
+}
+
+// shareClaims are signed into the tokens of share links.
+type shareClaims struct {
+	ID        string `json:"i"`
+	SessionID string `json:"s"`
+	Mode      string `json:"m"`
+	MaxUses   int    `json:"n,omitempty"`
+}
+
+// shareLinks mints share links and keeps track of their uses.
+// Links are valid only in the process minted them, like sessions.
+type shareLinks struct {
+	signer *token.Signer
+
+	mutex sync.Mutex
+	links map[string]*shareLink
+}
+
+type shareLinkKey struct{}
+
+func newShareLinks(signer *token.Signer) *shareLinks {
+	return &shareLinks{
+		signer: signer,
+		links:  map[string]*shareLink{},
+	}
+}
+
+// mint creates a new link and returns it with its token.
+func (sl *shareLinks) mint(sessionID string, mode string, ttl time.Duration, maxUses int, createdBy string) (*shareLink, string, error) {
+	link := &shareLink{
+		ID:        randomToken(),
+		SessionID: sessionID,
+		Mode:      mode,
+		Expires:   time.Now().Add(ttl).Truncate(time.Second),
+		MaxUses:   maxUses,
+		CreatedBy: createdBy,
+	}
+	raw, err := sl.signer.Sign(shareClaims{
+		ID:        link.ID,
+		SessionID: sessionID,
+		Mode:      mode,
+		MaxUses:   maxUses,
+	}, link.Expires)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to sign share link")
+	}
+
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+	sl.purge()
+	sl.links[link.ID] = link
+	copied := *link
+	return &copied, raw, nil
+}
+
+// check returns the link of raw if it is valid.
+func (sl *shareLinks) check(raw string) (*shareLink, error) {
+	var claims shareClaims
+	if _, err := sl.signer.Verify(raw, &claims); err != nil {
+		return nil, err
+	}
+
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+	link, err := sl.lookup(claims.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	if link.SessionID != claims.SessionID || link.Mode != claims.Mode {
+		return nil, errors.New("unknown share link")
+	}
+	copied := *link
+	return &copied, nil
+}
+
+// use counts a use of the link of id if it is still valid.
+// Uses are counted when the tokens minted with the link are redeemed,
+// so that the limit of the link applies to each connection.
+func (sl *shareLinks) use(id string) (*shareLink, error) {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+	link, err := sl.lookup(id, true)
+	if err != nil {
+		return nil, err
+	}
+	link.Uses++
+	copied := *link
+	return &copied, nil
+}
+
+// active returns an error unless the link of id is still valid,
+// regardless of its uses as for viewers resuming their sessions.
+func (sl *shareLinks) active(id string) error {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+	_, err := sl.lookup(id, false)
+	return err
+}
+
+// lookup returns the link of id unless it is expired or revoked,
+// or used up if uses is true.
+func (sl *shareLinks) lookup(id string, uses bool) (*shareLink, error) {
+	link, ok := sl.links[id]
+	if !ok {
+		return nil, errors.New("unknown share link")
+	}
+	if time.Now().After(link.Expires) {
+		return nil, errors.New("share link is expired")
+	}
+	if link.Revoked {
+		return nil, errors.New("share link is revoked")
+	}
+	if uses && link.MaxUses > 0 && link.Uses >= link.MaxUses {
+		return nil, errors.New("share link is used up")
+	}
+	return link, nil
+}
+
+// revoke makes the link of id invalid.
+func (sl *shareLinks) revoke(id string) (*shareLink, bool) {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+	link, ok := sl.links[id]
+	if !ok {
+		return nil, false
+	}
+	link.Revoked = true
+	copied := *link
+	return &copied, true
+}
+
+// list returns the links not expired yet in the order of their expiries.
+func (sl *shareLinks) list() []shareLink {
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+	sl.purge()
+	links := make([]shareLink, 0, len(sl.links))
+	for _, link := range sl.links {
+		links = append(links, *link)
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].Expires.Before(links[j].Expires)
+	})
+	return links
+}
+
+func (sl *shareLinks) purge() {
+	now := time.Now()
+	for id, link := range sl.links {
+		if now.After(link.Expires) {
+			delete(sl.links, id)
+		}
+	}
+}
+
+// sharedRequest is the share link a request is authorized with.
+type sharedRequest struct {
+	raw  string
+	link *shareLink
+}
+
+// wrapShareLink serves requests with a valid share link in the share parameter
+// with public, bypassing the authentication of handler.
+// public must only serve what the holders of links are allowed to,
+// as the links do not authenticate anyone.
+func (server *Server) wrapShareLink(handler http.Handler, public http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := r.URL.Query().Get("share")
+		if raw == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		link, err := server.shareLinks.check(raw)
+		if err != nil {
+			server.denyShareLink(w, r, err)
+			return
+		}
+		ctx := context.WithValue(r.Context(), shareLinkKey{}, sharedRequest{raw: raw, link: link})
+		public.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// bindShareLink counts a use of the share link tok is minted with, if any,
+// and authorizes the connection with it.
+// Connections with share links in their URLs must have tokens minted with the same links.
+func (server *Server) bindShareLink(tok *wsToken, id *clientIdentity) error {
+	if tok.Share == "" {
+		if id.Share != nil {
+			return errors.New("token is not minted with the share link")
+		}
+		return nil
+	}
+	if id.Share != nil && id.Share.ID != tok.Share {
+		return errors.New("token is minted with another share link")
+	}
+	link, err := server.shareLinks.use(tok.Share)
+	if err != nil {
+		return err
+	}
+	if link.SessionID != tok.SessionID {
+		return errors.New("token is minted for another session")
+	}
+	id.Share = link
+	return nil
+}
+
+func (server *Server) denyShareLink(w http.ResponseWriter, r *http.Request, err error) {
+	server.metrics.authFailures.Inc("share_link")
+	auditEvent(server.options.Auditor, audit.Event{
+		Type:       audit.EventAuthFailure,
+		RemoteAddr: r.RemoteAddr,
+		Reason:     "invalid share link: " + err.Error(),
+	})
+	http.Error(w, "invalid share link: "+err.Error(), http.StatusForbidden)
+}
+
+// requestShareLink returns the token and the link of the share link the request is authorized with, if any.
+func requestShareLink(r *http.Request) (string, *shareLink) {
+	shared, _ := r.Context().Value(shareLinkKey{}).(sharedRequest)
+	return shared.raw, shared.link
+}
+
+// handleAdminShareLinks serves the API for administrators to manage share links.
+//
+//	GET    {path}api/links       lists the links not expired yet
+//	DELETE {path}api/links/{id}  revokes the link
+func (server *Server) handleAdminShareLinks(prefix string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+		switch {
+		case id == "" && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, server.shareLinks.list())
+		case id != "" && r.Method == http.MethodDelete:
+			link, ok := server.shareLinks.revoke(id)
+			if !ok {
+				writeAdminError(w, http.StatusNotFound, "share link not found")
+				return
+			}
+			log.Printf("Share link %s of session %s revoked by administrator %s", link.ID, link.SessionID, r.RemoteAddr)
+			auditEvent(server.options.Auditor, audit.Event{
+				Type:       audit.EventShareRevoke,
+				SessionID:  link.SessionID,
+				User:       requestUser(r),
+				RemoteAddr: r.RemoteAddr,
+				Reason:     "share link " + link.ID,
+			})
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+}
+
+// shareRequest is the body of requests to mint share links.
+type shareRequest struct {
+	Mode string `json:"mode"`
+	// TTL is the lifetime of the link in seconds
+	TTL     int `json:"ttl"`
+	MaxUses int `json:"max_uses"`
+}
+
+// defaultShareTTL is the lifetime of share links without TTL given
+const defaultShareTTL = time.Hour
+
+// handleMintShareLink mints a share link of sess, serving
+//
+//	POST {path}api/sessions/{id}/links
+func (server *Server) handleMintShareLink(w http.ResponseWriter, r *http.Request, sess *session, pagePath string) {
+	if r.Method != http.MethodPost {
+		writeAdminError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req := shareRequest{Mode: shareModeReadOnly}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAdminError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+			return
+		}
+	}
+	if req.Mode != shareModeReadOnly && req.Mode != shareModeReadWrite {
+		writeAdminError(w, http.StatusBadRequest, "mode must be read-only or read-write")
+		return
+	}
+	if req.TTL < 0 || req.MaxUses < 0 {
+		writeAdminError(w, http.StatusBadRequest, "ttl and max_uses must not be negative")
+		return
+	}
+	ttl := defaultShareTTL
+	if req.TTL > 0 {
+		ttl = time.Duration(req.TTL) * time.Second
+	}
+
+	link, raw, err := server.shareLinks.mint(sess.id, req.Mode, ttl, req.MaxUses, requestUser(r))
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("Share link %s of session %s minted by administrator %s", link.ID, sess.id, r.RemoteAddr)
+	auditEvent(server.options.Auditor, audit.Event{
+		Type:       audit.EventShareCreate,
+		SessionID:  sess.id,
+		User:       requestUser(r),
+		RemoteAddr: r.RemoteAddr,
+		Reason:     "share link " + link.ID + " (" + link.Mode + ")",
+	})
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     publicPath(r, pagePath),
+		RawQuery: url.Values{"share": []string{raw}}.Encode(),
+	}
+	writeJSON(w, http.StatusCreated, struct {
+		*shareLink
+		URL   string `json:"url"`
+		Token string `json:"token"`
+	}{link, u.String(), raw})
+}
@@ -0,0 +1,309 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/pkg/token"
+	"github.com/labbs/webtty/webtty"
+)
+
+func newTestShareLinks(t *testing.T) (*shareLinks, *token.Signer) {
+	t.Helper()
+	signer, err := token.NewSigner([]byte("key"))
+	if err != nil {
+		t.Fatalf("NewSigner() = %v", err)
+	}
+	return newShareLinks(signer.Derive("share")), signer
+}
+
+func TestShareLinkCheck(t *testing.T) {
+	links, signer := newTestShareLinks(t)
+	link, raw, err := links.mint("session", shareModeReadOnly, time.Hour, 0, "admin")
+	if err != nil {
+		t.Fatalf("mint() = %v", err)
+	}
+	if got, err := links.check(raw); err != nil || got.ID != link.ID || got.SessionID != "session" {
+		t.Fatalf("check() = %+v, %v; want link of session", got, err)
+	}
+
+	otherSigner, _ := token.NewSigner([]byte("other key"))
+	otherKey, _ := otherSigner.Derive("share").Sign(shareClaims{ID: link.ID, SessionID: "session", Mode: shareModeReadOnly}, link.Expires)
+	otherPurpose, _ := signer.Derive("websocket").Sign(shareClaims{ID: link.ID, SessionID: "session", Mode: shareModeReadOnly}, link.Expires)
+	escalated, _ := signer.Derive("share").Sign(shareClaims{ID: link.ID, SessionID: "session", Mode: shareModeReadWrite}, link.Expires)
+	otherSession, _ := signer.Derive("share").Sign(shareClaims{ID: link.ID, SessionID: "other", Mode: shareModeReadOnly}, link.Expires)
+	unknown, _ := signer.Derive("share").Sign(shareClaims{ID: "unknown", SessionID: "session", Mode: shareModeReadOnly}, link.Expires)
+	_, expired, _ := links.mint("session", shareModeReadOnly, -time.Second, 0, "admin")
+	revokedLink, revoked, _ := links.mint("session", shareModeReadOnly, time.Hour, 0, "admin")
+	links.revoke(revokedLink.ID)
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "signed with other key", raw: otherKey},
+		{name: "signed for other purpose", raw: otherPurpose},
+		{name: "mode changed", raw: escalated},
+		{name: "session changed", raw: otherSession},
+		{name: "unknown", raw: unknown},
+		{name: "expired", raw: expired},
+		{name: "revoked", raw: revoked},
+		{name: "tampered", raw: raw[:len(raw)-2] + "xx"},
+		{name: "malformed", raw: "link"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := links.check(tt.raw); err == nil {
+				t.Errorf("check() = %+v, want error", got)
+			}
+		})
+	}
+}
+
+func TestShareLinkExpires(t *testing.T) {
+	links, _ := newTestShareLinks(t)
+	link, _, _ := links.mint("session", shareModeReadOnly, time.Hour, 0, "admin")
+
+	// expire the link kept by the server, as if the time passed
+	links.mutex.Lock()
+	links.links[link.ID].Expires = time.Now().Add(-time.Second)
+	links.mutex.Unlock()
+
+	if _, err := links.use(link.ID); err == nil {
+		t.Errorf("use() of expired link succeeded, want error")
+	}
+	if err := links.active(link.ID); err == nil {
+		t.Errorf("active() of expired link succeeded, want error")
+	}
+	if got := links.list(); len(got) != 0 {
+		t.Errorf("list() = %+v, want expired link purged", got)
+	}
+}
+
+func TestShareLinkMaxUses(t *testing.T) {
+	links, _ := newTestShareLinks(t)
+	link, raw, _ := links.mint("session", shareModeReadOnly, time.Hour, 2, "admin")
+
+	for i := 1; i <= 2; i++ {
+		got, err := links.use(link.ID)
+		if err != nil || got.Uses != i {
+			t.Fatalf("use() #%d = %+v, %v; want %d uses", i, got, err, i)
+		}
+	}
+	if _, err := links.use(link.ID); err == nil {
+		t.Errorf("use() beyond max uses succeeded, want error")
+	}
+	if _, err := links.check(raw); err == nil {
+		t.Errorf("check() of used up link succeeded, want error")
+	}
+	// viewers already connected with the link can still resume their sessions
+	if err := links.active(link.ID); err != nil {
+		t.Errorf("active() of used up link = %v", err)
+	}
+
+	links.revoke(link.ID)
+	if err := links.active(link.ID); err == nil {
+		t.Errorf("active() of revoked link succeeded, want error")
+	}
+}
+
+func TestBindShareLink(t *testing.T) {
+	links, _ := newTestShareLinks(t)
+	server := &Server{shareLinks: links}
+	link, _, _ := links.mint("session", shareModeReadOnly, time.Hour, 0, "admin")
+	other, _, _ := links.mint("session", shareModeReadOnly, time.Hour, 0, "admin")
+	revoked, _, _ := links.mint("session", shareModeReadOnly, time.Hour, 0, "admin")
+	links.revoke(revoked.ID)
+
+	tests := []struct {
+		name      string
+		token     wsToken
+		requested *shareLink
+		wantShare string
+		wantErr   bool
+	}{
+		{name: "no share link", token: wsToken{}},
+		{name: "minted with link", token: wsToken{Share: link.ID, SessionID: "session"}, wantShare: link.ID},
+		{name: "requested with same link", token: wsToken{Share: link.ID, SessionID: "session"}, requested: link, wantShare: link.ID},
+		{name: "requested without token for link", token: wsToken{}, requested: link, wantErr: true},
+		{name: "requested with other link", token: wsToken{Share: other.ID, SessionID: "session"}, requested: link, wantErr: true},
+		{name: "minted for other session", token: wsToken{Share: link.ID, SessionID: "other"}, wantErr: true},
+		{name: "revoked", token: wsToken{Share: revoked.ID, SessionID: "session"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := &clientIdentity{Share: tt.requested}
+			err := server.bindShareLink(&tt.token, id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bindShareLink() = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			share := ""
+			if id.Share != nil {
+				share = id.Share.ID
+			}
+			if share != tt.wantShare {
+				t.Errorf("share link = %q, want %q", share, tt.wantShare)
+			}
+		})
+	}
+}
+
+// testFactory counts the backends requested, failing to create any.
+type testFactory struct {
+	created int32
+}
+
+func (tf *testFactory) Name() string {
+	return "test"
+}
+
+func (tf *testFactory) New(params map[string][]string) (Slave, error) {
+	atomic.AddInt32(&tf.created, 1)
+	return nil, errors.New("no backend in tests")
+}
+
+// newShareTestServer serves a server protected with basic authentication,
+// with the admin API to mint share links.
+func newShareTestServer(t *testing.T) (*Server, *testFactory, *httptest.Server) {
+	t.Helper()
+	factory := &testFactory{}
+	server, err := New(factory, &Options{
+		Path:            "/",
+		PermitWrite:     true,
+		EnableBasicAuth: true,
+		Credential:      "user:pass",
+		AdminCredential: "admin:pass",
+		EnableMetrics:   true,
+	})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ts := httptest.NewServer(server.setupHandlers(ctx, cancel, "/", newCounter(0)))
+	t.Cleanup(ts.Close)
+	return server, factory, ts
+}
+
+func TestWrapShareLinkRoutes(t *testing.T) {
+	server, _, ts := newShareTestServer(t)
+	link, raw, _ := server.shareLinks.mint("session", shareModeReadOnly, time.Hour, 0, "admin")
+	share := "?" + url.Values{"share": []string{raw}}.Encode()
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "index", path: "/" + share, want: http.StatusOK},
+		{name: "static assets", path: "/static/js/bundle.js" + share, want: http.StatusOK},
+		{name: "token", path: "/token" + share, want: http.StatusOK},
+		{name: "metrics", path: "/metrics" + share, want: http.StatusNotFound},
+		{name: "other pages", path: "/unknown" + share, want: http.StatusNotFound},
+		{name: "invalid link", path: "/?share=link", want: http.StatusForbidden},
+		{name: "no link", path: "/", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + tt.path)
+			if err != nil {
+				t.Fatalf("GET %s = %v", tt.path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, resp.StatusCode, tt.want)
+			}
+		})
+	}
+
+	// tokens served with the link are bound to the link and its session
+	resp, err := http.Get(ts.URL + "/token" + share)
+	if err != nil {
+		t.Fatalf("GET /token = %v", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	tok, err := server.wsTokens.redeem(body.Token, strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("redeem() = %v", err)
+	}
+	if tok.Share != link.ID || tok.SessionID != "session" {
+		t.Errorf("token = %+v, want bound to link %s of session", tok, link.ID)
+	}
+}
+
+// connectWS opens a websocket connection to the server with the token
+// and waits until it is closed.
+func connectWS(t *testing.T, ts *httptest.Server, query string, authToken string) {
+	t.Helper()
+	dialer := websocket.Dialer{Subprotocols: []string{webtty.TextProtocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws"+query, nil)
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	defer conn.Close()
+	init, _ := json.Marshal(InitMessage{AuthToken: authToken})
+	if err := conn.WriteMessage(websocket.TextMessage, init); err != nil {
+		t.Fatalf("WriteMessage() = %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestShareLinkTokenNeverCreatesSession(t *testing.T) {
+	server, factory, ts := newShareTestServer(t)
+	origin := strings.TrimPrefix(ts.URL, "http://")
+	link, raw, _ := server.shareLinks.mint("session", shareModeReadOnly, time.Hour, 0, "admin")
+	share := "?" + url.Values{"share": []string{raw}}.Encode()
+
+	// the session of the link is gone, which must not start a new one
+	shareToken, _, _ := server.wsTokens.mint("", origin, link)
+	connectWS(t, ts, "", shareToken)
+	shareToken, _, _ = server.wsTokens.mint("", origin, link)
+	connectWS(t, ts, share, shareToken)
+	if created := atomic.LoadInt32(&factory.created); created != 0 {
+		t.Errorf("%d backends created with share links, want 0", created)
+	}
+	if got, _ := server.shareLinks.check(raw); got.Uses != 2 {
+		t.Errorf("link used %d times, want 2", got.Uses)
+	}
+
+	// tokens not minted with the link are rejected with it
+	plainToken, _, _ := server.wsTokens.mint("", origin, nil)
+	connectWS(t, ts, share, plainToken)
+	if created := atomic.LoadInt32(&factory.created); created != 0 {
+		t.Errorf("%d backends created with share links, want 0", created)
+	}
+
+	// while they still create sessions without it
+	plainToken, _, _ = server.wsTokens.mint("", origin, nil)
+	connectWS(t, ts, "", plainToken)
+	if created := atomic.LoadInt32(&factory.created); created != 1 {
+		t.Errorf("%d backends created without share links, want 1", created)
+	}
+}
@@ -15,3 +15,12 @@ type Factory interface {
 	Name() string
 	New(params map[string][]string) (Slave, error)
 }
+
+// ControlFactory is a Factory of slaves whose input only controls them,
+// such as playing back recordings, instead of being written to terminals.
+// Clients send input to the slaves without PermitWrite,
+// and the parameters named by ControlParameters without PermitArguments.
+type ControlFactory interface {
+	Factory
+	ControlParameters() []string
+}
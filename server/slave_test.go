@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/labbs/webtty/webtty"
+)
+
+// testSlave passes the input written to it to the channel.
+type testSlave struct {
+	input     chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (ts *testSlave) Read(p []byte) (int, error) {
+	<-ts.done
+	return 0, io.EOF
+}
+
+func (ts *testSlave) Write(p []byte) (int, error) {
+	ts.input <- append([]byte{}, p...)
+	return len(p), nil
+}
+
+func (ts *testSlave) WindowTitleVariables() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func (ts *testSlave) ResizeTerminal(columns int, rows int) error {
+	return nil
+}
+
+func (ts *testSlave) Close() error {
+	ts.closeOnce.Do(func() { close(ts.done) })
+	return nil
+}
+
+// testControlFactory creates testSlaves controlled with the speed parameter.
+type testControlFactory struct {
+	params chan map[string][]string
+	slave  *testSlave
+}
+
+func (tf *testControlFactory) Name() string {
+	return "test"
+}
+
+func (tf *testControlFactory) New(params map[string][]string) (Slave, error) {
+	tf.params <- params
+	return tf.slave, nil
+}
+
+func (tf *testControlFactory) ControlParameters() []string {
+	return []string{"speed"}
+}
+
+func TestControlFactory(t *testing.T) {
+	factory := &testControlFactory{
+		params: make(chan map[string][]string, 1),
+		slave:  &testSlave{input: make(chan []byte, 1), done: make(chan struct{})},
+	}
+	defer factory.slave.Close()
+	// neither writes nor arguments are permitted
+	server, err := New(factory, &Options{Path: "/"})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := httptest.NewServer(server.setupHandlers(ctx, cancel, "/", newCounter(0)))
+	defer ts.Close()
+
+	authToken, _, _ := server.wsTokens.mint("", strings.TrimPrefix(ts.URL, "http://"), nil)
+	dialer := websocket.Dialer{Subprotocols: []string{webtty.TextProtocol}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	defer conn.Close()
+	init, _ := json.Marshal(InitMessage{AuthToken: authToken, Arguments: "?speed=2&arg=ls"})
+	conn.WriteMessage(websocket.TextMessage, init)
+
+	select {
+	case params := <-factory.params:
+		if len(params) != 1 || len(params["speed"]) != 1 || params["speed"][0] != "2" {
+			t.Errorf("New() given %v, want only speed=2", params)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no slave created")
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte{webtty.Input, ' '})
+	select {
+	case input := <-factory.slave.input:
+		if string(input) != " " {
+			t.Errorf("slave received %q, want %q", input, " ")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("input not written to slave")
+	}
+}
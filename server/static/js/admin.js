@@ -0,0 +1,69 @@
+(function () {
+    var tbody = document.querySelector("#sessions tbody");
+    var status = document.getElementById("status");
+
+    function cell(row, text, className) {
+        var td = document.createElement("td");
+        td.textContent = text;
+        if (className) {
+            td.className = className;
+        }
+        row.appendChild(td);
+        return td;
+    }
+
+    function button(td, label, onclick) {
+        var b = document.createElement("button");
+        b.textContent = label;
+        b.addEventListener("click", onclick);
+        td.appendChild(b);
+    }
+
+    function render(sessions) {
+        tbody.textContent = "";
+        sessions.forEach(function (session) {
+            var row = document.createElement("tr");
+            cell(row, session.id);
+            cell(row, session.remote_addr);
+            cell(row, session.user || "");
+            cell(row, new Date(session.start_time).toLocaleString());
+            cell(row, [session.command].concat(session.argv || []).join(" "), "command");
+            cell(row, session.pid || "");
+            cell(row, session.viewers + (session.shadows ? " (+" + session.shadows + " shadow)" : ""));
+            cell(row, session.bytes_in);
+            cell(row, session.bytes_out);
+            var actions = cell(row, "");
+            button(actions, "Shadow", function () {
+                window.open(gotty_admin_shadow + "?session=" + encodeURIComponent(session.id), "_blank");
+            });
+            button(actions, "Kill", function () {
+                if (!window.confirm("Terminate session " + session.id + "?")) {
+                    return;
+                }
+                fetch(gotty_admin_api + "/" + encodeURIComponent(session.id), {
+                    method: "DELETE",
+                    credentials: "same-origin"
+                }).then(refresh);
+            });
+            tbody.appendChild(row);
+        });
+        status.textContent = sessions.length + " session(s), updated at " + new Date().toLocaleTimeString();
+    }
+
+    function refresh() {
+        fetch(gotty_admin_api, { credentials: "same-origin" })
+            .then(function (response) {
+                if (!response.ok) {
+                    throw new Error(response.status + " " + response.statusText);
+                }
+                return response.json();
+            })
+            .then(render)
+            .catch(function (err) {
+                status.textContent = "Failed to list sessions: " + err.message;
+            });
+    }
+
+    refresh();
+    setInterval(refresh, 2000);
+})();
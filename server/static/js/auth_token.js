@@ -1 +0,0 @@
-var gotty_auth_token = '';
\ No newline at end of file
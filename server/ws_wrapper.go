@@ -1,15 +1,34 @@
 package server
 
 import (
+	"encoding/base64"
+	"time"
+	"unicode/utf8"
+
 	"github.com/gorilla/websocket"
+
+	"github.com/labbs/webtty/webtty"
 )
 
 type wsWrapper struct {
 	*websocket.Conn
+	messageType int
+}
+
+func newWSWrapper(conn *websocket.Conn) *wsWrapper {
+	messageType := websocket.TextMessage
+	if conn.Subprotocol() == webtty.BinaryProtocol {
+		messageType = websocket.BinaryMessage
+	}
+
+	return &wsWrapper{
+		Conn:        conn,
+		messageType: messageType,
+	}
 }
 
 func (wsw *wsWrapper) Write(p []byte) (n int, err error) {
-	writer, err := wsw.Conn.NextWriter(websocket.TextMessage)
+	writer, err := wsw.Conn.NextWriter(wsw.messageType)
 	if err != nil {
 		return 0, err
 	}
@@ -24,10 +43,46 @@ func (wsw *wsWrapper) Read(p []byte) (n int, err error) {
 			return 0, err
 		}
 
-		if msgType != websocket.TextMessage {
+		if msgType != wsw.messageType {
 			continue
 		}
 
 		return reader.Read(p)
 	}
 }
+
+func (wsw *wsWrapper) isBinary() bool {
+	return wsw.messageType == websocket.BinaryMessage
+}
+
+// deny shows message in the terminal of the master and closes the connection
+// as a policy violation.
+func (wsw *wsWrapper) deny(message string) {
+	output := []byte("\r\n\x1b[31m" + message + "\x1b[0m\r\n")
+	if !wsw.isBinary() {
+		output = []byte(base64.StdEncoding.EncodeToString(output))
+	}
+	wsw.Write(append([]byte{webtty.Output}, output...))
+
+	wsw.Conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, closeReason(message)),
+		time.Now().Add(time.Second),
+	)
+}
+
+// maxCloseReason is the maximum length of close reasons in bytes.
+const maxCloseReason = 123
+
+// closeReason truncates message to fit in a close frame,
+// without splitting a character as the reason must be valid UTF-8.
+func closeReason(message string) string {
+	if len(message) <= maxCloseReason {
+		return message
+	}
+	end := maxCloseReason
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end]
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCloseReason(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{name: "short", message: "denied", want: "denied"},
+		{name: "at limit", message: strings.Repeat("a", 123), want: strings.Repeat("a", 123)},
+		{name: "ascii over limit", message: strings.Repeat("a", 130), want: strings.Repeat("a", 123)},
+		// é is 2 bytes, whose second byte would be the 124th
+		{name: "character across limit", message: strings.Repeat("a", 122) + "é", want: strings.Repeat("a", 122)},
+		// 日 is 3 bytes starting at the 122nd
+		{name: "wide character across limit", message: strings.Repeat("a", 121) + "日本", want: strings.Repeat("a", 121)},
+		{name: "character ending at limit", message: strings.Repeat("a", 121) + "é!", want: strings.Repeat("a", 121) + "é"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := closeReason(tt.message)
+			if got != tt.want {
+				t.Errorf("closeReason() = %q, want %q", got, tt.want)
+			}
+			if len(got) > maxCloseReason || !utf8.ValidString(got) {
+				t.Errorf("closeReason() = %q of %d bytes, not a valid close reason", got, len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/labbs/webtty/pkg/token"
+)
+
+// wsTokenLifetime is the time clients have to open the websocket connection with a token
+const wsTokenLifetime = time.Minute
+
+// wsTokens mints tokens that authenticate one websocket connection each.
+// Tokens are bound to the user and the origin of the page they are minted for,
+// and to the share link and its session if the page is opened with a share link.
+type wsTokens struct {
+	signer *token.Signer
+
+	mutex sync.Mutex
+	// used maps IDs of redeemed tokens to their expiries
+	used map[string]time.Time
+}
+
+type wsToken struct {
+	ID     string `json:"i"`
+	User   string `json:"u,omitempty"`
+	Origin string `json:"o"`
+	// Share is the ID of the share link and SessionID is the session it grants access to,
+	// which is the only session the token is valid for if Share is not empty
+	Share     string `json:"s,omitempty"`
+	SessionID string `json:"e,omitempty"`
+}
+
+func newWSTokens(signer *token.Signer) *wsTokens {
+	return &wsTokens{
+		signer: signer,
+		used:   map[string]time.Time{},
+	}
+}
+
+// mint returns a new token for user at origin, bound to share unless it is nil.
+func (tokens *wsTokens) mint(user string, origin string, share *shareLink) (string, time.Time, error) {
+	expires := time.Now().Add(wsTokenLifetime)
+	tok := wsToken{ID: randomToken(), User: user, Origin: origin}
+	if share != nil {
+		tok.Share = share.ID
+		tok.SessionID = share.SessionID
+	}
+	raw, err := tokens.signer.Sign(tok, expires)
+	if err != nil {
+		return "", time.Time{}, errors.Wrapf(err, "failed to mint a websocket token")
+	}
+	return raw, expires, nil
+}
+
+// redeem verifies raw is minted for origin and not used yet, and returns the token.
+func (tokens *wsTokens) redeem(raw string, origin string) (*wsToken, error) {
+	var tok wsToken
+	expires, err := tokens.signer.Verify(raw, &tok)
+	if err != nil {
+		return nil, err
+	}
+	if tok.Origin != origin {
+		return nil, errors.New("token is minted for another origin")
+	}
+
+	tokens.mutex.Lock()
+	defer tokens.mutex.Unlock()
+	now := time.Now()
+	for id, exp := range tokens.used {
+		if now.After(exp) {
+			delete(tokens.used, id)
+		}
+	}
+	if _, ok := tokens.used[tok.ID]; ok {
+		return nil, errors.New("token is already used")
+	}
+	tokens.used[tok.ID] = expires
+	return &tok, nil
+}
+
+// requestOrigin returns the host the client of r loaded the page from,
+// which is the host of the Origin header sent by browsers, or the host requested.
+func requestOrigin(r *http.Request) string {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return r.Host
+}
+
+// handleWSToken mints a token for clients to open another websocket connection,
+// such as reconnecting.
+func (server *Server) handleWSToken(w http.ResponseWriter, r *http.Request) {
+	_, share := requestShareLink(r)
+	raw, expires, err := server.wsTokens.mint(requestUser(r), requestOrigin(r), share)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token":   raw,
+		"expires": expires,
+	})
+}
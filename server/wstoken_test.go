@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labbs/webtty/pkg/token"
+)
+
+func newTestWSTokens(t *testing.T) (*wsTokens, *token.Signer) {
+	t.Helper()
+	signer, err := token.NewSigner([]byte("key"))
+	if err != nil {
+		t.Fatalf("NewSigner() = %v", err)
+	}
+	return newWSTokens(signer.Derive("websocket")), signer
+}
+
+func TestWSTokenRedeemedOnce(t *testing.T) {
+	tokens, _ := newTestWSTokens(t)
+	raw, expires, err := tokens.mint("alice", "example.com", nil)
+	if err != nil {
+		t.Fatalf("mint() = %v", err)
+	}
+	if !expires.After(time.Now()) {
+		t.Errorf("token expires at %s, want in the future", expires)
+	}
+
+	tok, err := tokens.redeem(raw, "example.com")
+	if err != nil || tok.User != "alice" || tok.Share != "" {
+		t.Fatalf("redeem() = %+v, %v; want alice without share link", tok, err)
+	}
+	if _, err := tokens.redeem(raw, "example.com"); err == nil {
+		t.Errorf("second redeem() succeeded, want error")
+	}
+}
+
+func TestWSTokenRedeemRejects(t *testing.T) {
+	tokens, signer := newTestWSTokens(t)
+	raw, _, _ := tokens.mint("alice", "example.com", nil)
+	expired, _ := signer.Derive("websocket").Sign(wsToken{ID: "expired", User: "alice", Origin: "example.com"}, time.Now().Add(-time.Second))
+	otherPurpose, _ := signer.Derive("session").Sign(wsToken{ID: "other", User: "alice", Origin: "example.com"}, time.Now().Add(time.Minute))
+
+	tests := []struct {
+		name   string
+		token  string
+		origin string
+	}{
+		{name: "other origin", token: raw, origin: "evil.example.com"},
+		{name: "expired", token: expired, origin: "example.com"},
+		{name: "signed for other purpose", token: otherPurpose, origin: "example.com"},
+		{name: "malformed", token: "token", origin: "example.com"},
+		{name: "empty", token: "", origin: "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tok, err := tokens.redeem(tt.token, tt.origin); err == nil {
+				t.Errorf("redeem() = %+v, want error", tok)
+			}
+		})
+	}
+
+	// tokens rejected for the origin are still valid for the right one
+	if _, err := tokens.redeem(raw, "example.com"); err != nil {
+		t.Errorf("redeem() = %v after rejected for another origin", err)
+	}
+}
+
+func TestHandleWSToken(t *testing.T) {
+	tokens, _ := newTestWSTokens(t)
+	server := &Server{wsTokens: tokens}
+
+	r := httptest.NewRequest("GET", "http://example.com/token", nil)
+	r.Header.Set("Origin", "https://example.com")
+	w := httptest.NewRecorder()
+	server.handleWSToken(w, r)
+
+	var body struct {
+		Token   string    `json:"token"`
+		Expires time.Time `json:"expires"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if _, err := tokens.redeem(body.Token, "example.com"); err != nil {
+		t.Errorf("redeem() of the token served = %v", err)
+	}
+}
+
+func TestRequestOrigin(t *testing.T) {
+	for origin, want := range map[string]string{
+		"https://example.com:8080": "example.com:8080",
+		"":                         "webtty.example.com",
+		"null":                     "webtty.example.com",
+	} {
+		r := httptest.NewRequest("GET", "http://webtty.example.com/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := requestOrigin(r); got != want {
+			t.Errorf("requestOrigin() with Origin %q = %q, want %q", origin, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// shareOptions are the options of the share command, which manages
+// share links of a running server with the admin API.
+type shareOptions struct {
+	Server             string
+	InsecureSkipVerify bool
+	Mode               string
+	TTL                int
+	MaxUses            int
+}
+
+var shareClientOptions *shareOptions = &shareOptions{}
+
+// shareLink is a share link described by the admin API.
+type shareLink struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Mode      string    `json:"mode"`
+	Expires   time.Time `json:"expires"`
+	MaxUses   int       `json:"max_uses"`
+	Uses      int       `json:"uses"`
+	Revoked   bool      `json:"revoked"`
+	URL       string    `json:"url"`
+}
+
+func shareCreateAction(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf("Error: No session ID given.")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"mode":     shareClientOptions.Mode,
+		"ttl":      shareClientOptions.TTL,
+		"max_uses": shareClientOptions.MaxUses,
+	})
+	if err != nil {
+		return err
+	}
+	var link shareLink
+	path := "api/sessions/" + url.PathEscape(c.Args().First()) + "/links"
+	if err := shareRequest(http.MethodPost, path, body, &link); err != nil {
+		return shareExit(err)
+	}
+	fmt.Println(link.URL)
+	return nil
+}
+
+func shareListAction(c *cli.Context) error {
+	var links []shareLink
+	if err := shareRequest(http.MethodGet, "api/links", nil, &links); err != nil {
+		return shareExit(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSESSION\tMODE\tEXPIRES\tUSES\tREVOKED")
+	for _, link := range links {
+		uses := fmt.Sprintf("%d", link.Uses)
+		if link.MaxUses > 0 {
+			uses = fmt.Sprintf("%d/%d", link.Uses, link.MaxUses)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n",
+			link.ID, link.SessionID, link.Mode, link.Expires.Local().Format(time.RFC3339), uses, link.Revoked)
+	}
+	return w.Flush()
+}
+
+func shareRevokeAction(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf("Error: No share link ID given.")
+	}
+	if err := shareRequest(http.MethodDelete, "api/links/"+url.PathEscape(c.Args().First()), nil, nil); err != nil {
+		return shareExit(err)
+	}
+	fmt.Printf("Share link %s revoked\n", c.Args().First())
+	return nil
+}
+
+// shareExit makes the command print err and exit with an error status.
+func shareExit(err error) error {
+	return cli.Exit("Error: "+err.Error(), 1)
+}
+
+// shareRequest requests the admin API at path under the server URL,
+// decoding the response into v unless v is nil.
+func shareRequest(method string, path string, body []byte, v interface{}) error {
+	if appOptions.AdminCredential == "" {
+		return errors.New("admin credential is not given")
+	}
+	base := shareClientOptions.Server
+	if !strings.HasSuffix(base, "/") {
+		base = base + "/"
+	}
+
+	req, err := http.NewRequest(method, base+path, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "invalid server URL `%s`", shareClientOptions.Server)
+	}
+	name, password, _ := strings.Cut(appOptions.AdminCredential, ":")
+	req.SetBasicAuth(name, password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	if shareClientOptions.InsecureSkipVerify {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to request the admin API")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Error == "" {
+			apiErr.Error = strings.TrimSpace(string(data))
+		}
+		return errors.Errorf("admin API returned %s: %s", resp.Status, apiErr.Error)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return errors.Wrapf(err, "failed to decode the response of the admin API")
+	}
+	return nil
+}
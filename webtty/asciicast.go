@@ -0,0 +1,87 @@
+package webtty
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AsciicastHeader is the header line of an asciicast v2 recording.
+type AsciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// AsciicastRecorder is a RecordingSession writing events
+// to a stream in the asciicast v2 format.
+type AsciicastRecorder struct {
+	writer io.WriteCloser
+	start  time.Time
+	mutex  sync.Mutex
+}
+
+// NewAsciicastRecorder creates a new instance of AsciicastRecorder
+// and writes the header describing the session to writer.
+// The recorder closes writer when closed.
+func NewAsciicastRecorder(writer io.WriteCloser, info RecordingInfo) (*AsciicastRecorder, error) {
+	header := AsciicastHeader{
+		Version:   2,
+		Width:     info.Width,
+		Height:    info.Height,
+		Timestamp: info.StartTime.Unix(),
+		Title:     info.Command,
+	}
+	for _, arg := range info.Argv {
+		header.Title += " " + arg
+	}
+	if info.Term != "" {
+		header.Env = map[string]string{"TERM": info.Term}
+	}
+
+	line, err := json.Marshal(header)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal asciicast header")
+	}
+	_, err = writer.Write(append(line, '\n'))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write asciicast header")
+	}
+
+	return &AsciicastRecorder{
+		writer: writer,
+		start:  info.StartTime,
+	}, nil
+}
+
+// WriteEvent writes the event as a line of the recording.
+func (rec *AsciicastRecorder) WriteEvent(event RecordingEvent) error {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	elapsed := event.Time.Sub(rec.start).Seconds()
+	line, err := json.Marshal([]interface{}{elapsed, event.Type, event.Data})
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal asciicast event")
+	}
+	_, err = rec.writer.Write(append(line, '\n'))
+	if err != nil {
+		return errors.Wrapf(err, "failed to write asciicast event")
+	}
+
+	return nil
+}
+
+// Close closes the underlying writer.
+func (rec *AsciicastRecorder) Close() error {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+
+	return rec.writer.Close()
+}
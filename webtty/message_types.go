@@ -1,8 +1,18 @@
 package webtty
 
-// Protocols defines the name of this protocol,
-// which is supposed to be used to the subprotocol of Websockt streams.
-var Protocols = []string{"webtty"}
+// Protocols defines the names of this protocol,
+// which are supposed to be used to the subprotocol of Websockt streams.
+// They are listed in the order of preference.
+var Protocols = []string{BinaryProtocol, TextProtocol}
+
+const (
+	// TextProtocol sends every message in a text frame,
+	// output is encoded with base64
+	TextProtocol = "webtty"
+	// BinaryProtocol sends every message in a binary frame,
+	// output is sent as it is
+	BinaryProtocol = "webtty.binary"
+)
 
 const (
 	// Unknown message type, maybe sent by a bug
@@ -28,4 +38,8 @@ const (
 	SetPreferences = '4'
 	// Make terminal to reconnect
 	SetReconnect = '5'
+	// Notify the session the terminal is attached to
+	SetSession = '6'
+	// Notify an event occurred in the session, such as a master joining
+	SessionEvent = '7'
 )
@@ -17,6 +17,15 @@ func WithPermitWrite() Option {
 	}
 }
 
+// WithBinaryProtocol sets a WebTTY to send output to the master without encoding.
+// The master is supposed to transfer messages in binary frames.
+func WithBinaryProtocol() Option {
+	return func(wt *WebTTY) error {
+		wt.binary = true
+		return nil
+	}
+}
+
 // WithFixedColumns sets a fixed width to TTY master.
 func WithFixedColumns(columns int) Option {
 	return func(wt *WebTTY) error {
@@ -49,6 +58,25 @@ func WithReconnect(timeInSeconds int) Option {
 	}
 }
 
+// WithSession sets the ID of the session shared by the master
+// and the token for the master to resume the session.
+func WithSession(id string, token string) Option {
+	return func(wt *WebTTY) error {
+		wt.sessionID = id
+		wt.sessionToken = token
+		return nil
+	}
+}
+
+// WithWriteDeniedHandler sets a handler called
+// whenever input from the master is discarded as writing is not permitted.
+func WithWriteDeniedHandler(handler func()) Option {
+	return func(wt *WebTTY) error {
+		wt.onWriteDenied = handler
+		return nil
+	}
+}
+
 // WithMasterPreferences sets an optional configuration of master.
 func WithMasterPreferences(preferences interface{}) Option {
 	return func(wt *WebTTY) error {
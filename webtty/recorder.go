@@ -0,0 +1,135 @@
+package webtty
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Output of the slave
+	EventOutput = "o"
+	// Input to the slave
+	EventInput = "i"
+	// Resize of the terminal, data is formatted as `{columns}x{rows}`
+	EventResize = "r"
+)
+
+// Recorder stores terminal events of sessions.
+type Recorder interface {
+	// OpenSession starts recording a session.
+	OpenSession(info RecordingInfo) (RecordingSession, error)
+}
+
+// RecordingSession receives the events of a session being recorded.
+// Its methods can be called from multiple goroutines.
+type RecordingSession interface {
+	// WriteEvent records an event of the session.
+	WriteEvent(event RecordingEvent) error
+
+	// Close finishes recording the session.
+	Close() error
+}
+
+// RecordingInfo describes a session being recorded.
+type RecordingInfo struct {
+	SessionID  string    `json:"session_id"`
+	RemoteAddr string    `json:"remote_addr"`
+	StartTime  time.Time `json:"start_time"`
+	Command    string    `json:"command,omitempty"`
+	Argv       []string  `json:"argv,omitempty"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	Term       string    `json:"term,omitempty"`
+}
+
+// RecordingEvent is an event occurred in a session.
+type RecordingEvent struct {
+	Time time.Time
+	// One of EventOutput, EventInput and EventResize
+	Type string
+	// Always a valid UTF-8 string
+	Data string
+}
+
+// RecordedSlave is a Slave sending its output, input and resize events
+// to a RecordingSession.
+type RecordedSlave struct {
+	Slave
+	session RecordingSession
+
+	// incomplete UTF-8 characters held until the next read or write
+	pendingOutput []byte
+	pendingInput  []byte
+}
+
+// NewRecordedSlave creates a new instance of RecordedSlave.
+func NewRecordedSlave(slave Slave, session RecordingSession) *RecordedSlave {
+	return &RecordedSlave{
+		Slave:   slave,
+		session: session,
+	}
+}
+
+func (rs *RecordedSlave) Read(p []byte) (n int, err error) {
+	n, err = rs.Slave.Read(p)
+	if n > 0 {
+		rs.pendingOutput = rs.record(EventOutput, append(rs.pendingOutput, p[:n]...))
+	}
+	return n, err
+}
+
+func (rs *RecordedSlave) Write(p []byte) (n int, err error) {
+	// record before writing so that the input precedes its echo
+	rs.pendingInput = rs.record(EventInput, append(rs.pendingInput, p...))
+	return rs.Slave.Write(p)
+}
+
+func (rs *RecordedSlave) ResizeTerminal(columns int, rows int) error {
+	err := rs.Slave.ResizeTerminal(columns, rows)
+	if err == nil {
+		rs.record(EventResize, []byte(fmt.Sprintf("%dx%d", columns, rows)))
+	}
+	return err
+}
+
+// Close finishes recording. The slave is left intact.
+func (rs *RecordedSlave) Close() error {
+	return rs.session.Close()
+}
+
+// record sends the complete UTF-8 characters in data as an event
+// and returns the rest.
+func (rs *RecordedSlave) record(typ string, data []byte) []byte {
+	complete := completeUTF8(data)
+	if complete == 0 {
+		return data
+	}
+
+	err := rs.session.WriteEvent(RecordingEvent{
+		Time: time.Now(),
+		Type: typ,
+		Data: string(data[:complete]),
+	})
+	if err != nil {
+		log.Printf("Failed to record an event: %s", err)
+	}
+
+	return append([]byte{}, data[complete:]...)
+}
+
+// completeUTF8 returns the length of data without
+// an incomplete UTF-8 character at its end.
+func completeUTF8(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(data[i]) {
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			return i
+		}
+		break
+	}
+	return len(data)
+}
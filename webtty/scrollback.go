@@ -0,0 +1,250 @@
+package webtty
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Control sequences the scrollback interprets
+var (
+	// full reset, after which the output written before has no effect
+	seqReset = []byte("\x1bc")
+	// erase the display and saved lines
+	seqEraseSaved = []byte("\x1b[3J")
+	// erase the whole display, which only discards output of the alternate screen
+	seqEraseDisplay = []byte("\x1b[2J")
+	// switch to and back from the alternate screen used by full-screen applications
+	seqAlternateOn  = []byte("\x1b[?1049h")
+	seqAlternateOff = []byte("\x1b[?1049l")
+)
+
+var sequences = [][]byte{seqReset, seqEraseSaved, seqEraseDisplay, seqAlternateOn, seqAlternateOff}
+
+// maxSequenceLength is the length of the longest sequence in sequences.
+const maxSequenceLength = 8
+
+// Scrollback is a bounded ring buffer keeping the latest output of a slave,
+// so that it can be replayed to masters attaching to the slave later.
+// Output written before a full reset or an erase of saved lines is discarded.
+// Output to the alternate screen is kept apart from the primary screen,
+// whose history is restored when applications leave the alternate screen.
+type Scrollback struct {
+	primary   *ring
+	alternate *ring
+	// true while the slave draws on the alternate screen
+	inAlternate bool
+	// trailing bytes of the last write which may begin a sequence
+	pending []byte
+
+	mutex sync.Mutex
+}
+
+// NewScrollback creates a new instance of Scrollback keeping up to size bytes
+// for each of the primary and the alternate screens.
+func NewScrollback(size int) *Scrollback {
+	return &Scrollback{
+		primary:   newRing(size),
+		alternate: newRing(size),
+	}
+}
+
+// Write appends output of the slave to the scrollback.
+func (sb *Scrollback) Write(p []byte) (n int, err error) {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+
+	if sb.primary.size() == 0 {
+		return len(p), nil
+	}
+
+	// sequences can be split into two writes
+	data := append(sb.pending, p...)
+	sb.pending = nil
+	for len(data) > 0 {
+		i, seq := firstSequence(data)
+		if i < 0 {
+			keep := partialSequenceLength(data)
+			sb.current().append(data[:len(data)-keep])
+			sb.pending = append([]byte{}, data[len(data)-keep:]...)
+			break
+		}
+		sb.current().append(data[:i])
+		sb.apply(seq)
+		data = data[i+len(seq):]
+	}
+
+	return len(p), nil
+}
+
+func (sb *Scrollback) current() *ring {
+	if sb.inAlternate {
+		return sb.alternate
+	}
+	return sb.primary
+}
+
+func (sb *Scrollback) apply(seq []byte) {
+	switch {
+	case bytes.Equal(seq, seqReset):
+		sb.inAlternate = false
+		sb.alternate.reset()
+		sb.primary.reset()
+		sb.primary.append(seq)
+	case bytes.Equal(seq, seqEraseSaved):
+		sb.primary.reset()
+		sb.current().append(seq)
+	case bytes.Equal(seq, seqEraseDisplay) && sb.inAlternate:
+		sb.alternate.reset()
+		sb.alternate.append(seqAlternateOn)
+		sb.alternate.append(seq)
+	case bytes.Equal(seq, seqAlternateOn) && !sb.inAlternate:
+		sb.inAlternate = true
+		sb.alternate.reset()
+		sb.alternate.append(seq)
+	case bytes.Equal(seq, seqAlternateOff) && sb.inAlternate:
+		// the primary screen is restored as it was before switching
+		sb.inAlternate = false
+		sb.alternate.reset()
+	default:
+		sb.current().append(seq)
+	}
+}
+
+// Bytes returns the output kept in the scrollback.
+// When older output has been dropped, the returned bytes start
+// at the beginning of a line so that no partial sequence is included.
+// While the slave draws on the alternate screen, the output of the primary
+// screen is followed by the output of the alternate screen.
+func (sb *Scrollback) Bytes() []byte {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+
+	data := sb.primary.bytes()
+	if sb.inAlternate {
+		alternate := sb.alternate.bytes()
+		if !bytes.HasPrefix(alternate, seqAlternateOn) {
+			data = append(data, seqAlternateOn...)
+		}
+		data = append(data, alternate...)
+	}
+	return append(data, sb.pending...)
+}
+
+// Replay returns the output kept in the scrollback preceded by a full reset,
+// which is supposed to be sent to a master before live output.
+// It returns nil when the scrollback is empty.
+func (sb *Scrollback) Replay() []byte {
+	data := sb.Bytes()
+	if len(data) == 0 {
+		return nil
+	}
+
+	return append([]byte("\x1bc"), data...)
+}
+
+// Len returns the number of bytes kept in the scrollback.
+func (sb *Scrollback) Len() int {
+	sb.mutex.Lock()
+	defer sb.mutex.Unlock()
+
+	return sb.primary.length + sb.alternate.length + len(sb.pending)
+}
+
+// ring is a bounded buffer keeping the latest bytes appended.
+type ring struct {
+	buffer []byte
+	start  int
+	length int
+	// true when the oldest bytes may be a fragment of a sequence or a character
+	truncated bool
+}
+
+func newRing(size int) *ring {
+	return &ring{buffer: make([]byte, size)}
+}
+
+func (r *ring) size() int {
+	return len(r.buffer)
+}
+
+func (r *ring) reset() {
+	r.start = 0
+	r.length = 0
+	r.truncated = false
+}
+
+func (r *ring) append(p []byte) {
+	size := len(r.buffer)
+	if size == 0 || len(p) == 0 {
+		return
+	}
+	if len(p) >= size {
+		r.truncated = r.truncated || r.length > 0 || len(p) > size
+		copy(r.buffer, p[len(p)-size:])
+		r.start = 0
+		r.length = size
+		return
+	}
+
+	end := (r.start + r.length) % size
+	n := copy(r.buffer[end:], p)
+	copy(r.buffer, p[n:])
+
+	r.length += len(p)
+	if r.length > size {
+		r.start = (r.start + r.length - size) % size
+		r.length = size
+		r.truncated = true
+	}
+}
+
+// bytes returns a copy of the bytes in the ring, starting at the beginning
+// of a line when older bytes have been dropped.
+func (r *ring) bytes() []byte {
+	data := make([]byte, r.length)
+	if r.length > 0 {
+		copied := copy(data, r.buffer[r.start:])
+		copy(data[copied:], r.buffer)
+	}
+	if !r.truncated {
+		return data
+	}
+
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return data[i+1:]
+	}
+	// at least never start in the middle of a UTF-8 character
+	for len(data) > 0 && data[0]&0xC0 == 0x80 {
+		data = data[1:]
+	}
+	return data
+}
+
+// firstSequence returns the index and the sequence found first in data,
+// or -1 if data contains none of them.
+func firstSequence(data []byte) (int, []byte) {
+	first, found := -1, []byte(nil)
+	for _, seq := range sequences {
+		if i := bytes.Index(data, seq); i >= 0 && (first < 0 || i < first) {
+			first, found = i, seq
+		}
+	}
+	return first, found
+}
+
+// partialSequenceLength returns the length of the longest suffix of data
+// which is a proper prefix of a sequence.
+func partialSequenceLength(data []byte) int {
+	for n := maxSequenceLength - 1; n > 0; n-- {
+		if n > len(data) {
+			continue
+		}
+		suffix := data[len(data)-n:]
+		for _, seq := range sequences {
+			if len(seq) > n && bytes.HasPrefix(seq, suffix) {
+				return n
+			}
+		}
+	}
+	return 0
+}
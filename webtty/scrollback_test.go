@@ -0,0 +1,120 @@
+package webtty
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScrollback(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		writes []string
+		want   string
+	}{
+		{
+			name:   "plain output",
+			size:   64,
+			writes: []string{"foo\r\n", "bar\r\n"},
+			want:   "foo\r\nbar\r\n",
+		},
+		{
+			name:   "full reset discards earlier output",
+			size:   64,
+			writes: []string{"foo\r\n", "\x1bcbar"},
+			want:   "\x1bcbar",
+		},
+		{
+			name:   "erasing saved lines discards earlier output",
+			size:   64,
+			writes: []string{"foo\r\n\x1b[3J", "bar"},
+			want:   "\x1b[3Jbar",
+		},
+		{
+			name:   "reset split across writes",
+			size:   64,
+			writes: []string{"foo\r\n\x1b[", "3Jbar"},
+			want:   "\x1b[3Jbar",
+		},
+		{
+			name:   "erasing the primary display keeps history",
+			size:   64,
+			writes: []string{"foo\r\n", "\x1b[2Jbar"},
+			want:   "foo\r\n\x1b[2Jbar",
+		},
+		{
+			name:   "alternate screen keeps primary history",
+			size:   64,
+			writes: []string{"foo\r\n", "\x1b[?1049hvim"},
+			want:   "foo\r\n\x1b[?1049hvim",
+		},
+		{
+			name:   "erasing the alternate display discards its output only",
+			size:   64,
+			writes: []string{"foo\r\n", "\x1b[?1049hvim", "\x1b[2Jless"},
+			want:   "foo\r\n\x1b[?1049h\x1b[2Jless",
+		},
+		{
+			name:   "leaving the alternate screen restores primary history",
+			size:   64,
+			writes: []string{"foo\r\n", "\x1b[?1049hvim\x1b[?104", "9lbar"},
+			want:   "foo\r\nbar",
+		},
+		{
+			name:   "full reset leaves the alternate screen",
+			size:   64,
+			writes: []string{"foo\r\n\x1b[?1049hvim", "\x1bcbar"},
+			want:   "\x1bcbar",
+		},
+		{
+			name:   "truncated output starts at a line",
+			size:   8,
+			writes: []string{"foo\r\nbar\r\nbaz"},
+			want:   "baz",
+		},
+		{
+			name:   "disabled",
+			size:   0,
+			writes: []string{"foo\r\n"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sb := NewScrollback(tt.size)
+			for _, w := range tt.writes {
+				n, err := sb.Write([]byte(w))
+				if err != nil || n != len(w) {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := string(sb.Bytes()); got != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrollbackReplay(t *testing.T) {
+	sb := NewScrollback(64)
+	if replay := sb.Replay(); replay != nil {
+		t.Errorf("Replay() of empty scrollback = %q, want nil", replay)
+	}
+
+	sb.Write([]byte("foo\r\n"))
+	if got, want := string(sb.Replay()), "\x1bcfoo\r\n"; got != want {
+		t.Errorf("Replay() = %q, want %q", got, want)
+	}
+}
+
+func TestScrollbackTruncatedAlternateScreen(t *testing.T) {
+	sb := NewScrollback(16)
+	sb.Write([]byte("foo\r\n\x1b[?1049h"))
+	sb.Write([]byte(strings.Repeat("x", 20) + "\r\nvim"))
+
+	// the switch to the alternate screen is dropped from its ring, but replayed
+	if got, want := string(sb.Bytes()), "foo\r\n\x1b[?1049hvim"; got != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+}
@@ -18,12 +18,17 @@ type WebTTY struct {
 	// PTY Slave
 	slave Slave
 
-	windowTitle []byte
-	permitWrite bool
-	columns     int
-	rows        int
-	reconnect   int // in seconds
-	masterPrefs []byte
+	windowTitle  []byte
+	permitWrite  bool
+	binary       bool
+	columns      int
+	rows         int
+	reconnect    int // in seconds
+	masterPrefs  []byte
+	sessionID    string
+	sessionToken string
+	// called when input is discarded as writing is not permitted
+	onWriteDenied func()
 
 	terminalBuffer string
 	isEnter        bool
@@ -44,6 +49,7 @@ func New(masterConn Master, slave Slave, options ...Option) (*WebTTY, error) {
 		slave:      slave,
 
 		permitWrite: false,
+		binary:      false,
 		columns:     0,
 		rows:        0,
 
@@ -139,12 +145,42 @@ func (wt *WebTTY) sendInitializeMessage() error {
 		}
 	}
 
+	if wt.sessionID != "" {
+		session, _ := json.Marshal(argSetSession{ID: wt.sessionID, Token: wt.sessionToken, ReadOnly: !wt.permitWrite})
+		err := wt.masterWrite(append([]byte{SetSession}, session...))
+		if err != nil {
+			return errors.Wrapf(err, "failed to set session")
+		}
+	}
+
+	return nil
+}
+
+// SendSessionEvent notifies the master of an event occurred in the session.
+// event is sent as JSON.
+func (wt *WebTTY) SendSessionEvent(event interface{}) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal session event as JSON")
+	}
+
+	err = wt.masterWrite(append([]byte{SessionEvent}, payload...))
+	if err != nil {
+		return errors.Wrapf(err, "failed to send session event")
+	}
+
 	return nil
 }
 
 func (wt *WebTTY) handleSlaveReadEvent(data []byte) error {
-	safeMessage := base64.StdEncoding.EncodeToString(data)
-	err := wt.masterWrite(append([]byte{Output}, []byte(safeMessage)...))
+	var message []byte
+	if wt.binary {
+		message = append([]byte{Output}, data...)
+	} else {
+		safeMessage := base64.StdEncoding.EncodeToString(data)
+		message = append([]byte{Output}, []byte(safeMessage)...)
+	}
+	err := wt.masterWrite(message)
 	if err != nil {
 		return errors.Wrapf(err, "failed to send message to master")
 	}
@@ -172,6 +208,9 @@ func (wt *WebTTY) handleMasterReadEvent(data []byte) error {
 	switch data[0] {
 	case Input:
 		if !wt.permitWrite {
+			if wt.onWriteDenied != nil {
+				wt.onWriteDenied()
+			}
 			return nil
 		}
 
@@ -230,3 +269,9 @@ type argResizeTerminal struct {
 	Columns float64
 	Rows    float64
 }
+
+type argSetSession struct {
+	ID       string
+	Token    string
+	ReadOnly bool
+}